@@ -1,6 +1,7 @@
 package fit
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"time"
@@ -10,28 +11,48 @@ import (
 	"gonum.org/v1/gonum/stat"
 )
 
+var ErrNoRecords = errors.New("file contains no records")
+
 type Activity struct {
-	Type         string            `json:"type"`
-	StartTime    time.Time         `json:"start_time"`
-	EndTime      time.Time         `json:"end_time"`
-	Measurements []*Measurement    `json:"measurements" hash:"ignore"`
-	Correlations []*Correlation    `json:"correlations" hash:"ignore"`
-	Tags         map[string]string `json:"tags" hash:"ignore"`
-
-	mmap     map[string]*Measurement `json:"-"`
-	startPos *geodist.Coord          `json:"-"`
+	Type           string             `json:"type" hash:"ignore"`
+	StartTime      time.Time          `json:"start_time"`
+	EndTime        time.Time          `json:"end_time"`
+	ElapsedTime    float64            `json:"elapsed_time,omitempty" hash:"ignore"` // seconds
+	TimerTime      float64            `json:"timer_time,omitempty" hash:"ignore"`   // seconds
+	MovingTime     float64            `json:"moving_time,omitempty" hash:"ignore"`  // seconds
+	Pauses         []*Pause           `json:"pauses,omitempty" hash:"ignore"`
+	Measurements   []*Measurement     `json:"measurements" hash:"ignore"`
+	Correlations   []*Correlation     `json:"correlations" hash:"ignore"`
+	Laps           []*LapSummary      `json:"laps" hash:"ignore"`
+	Sessions       []*LapSummary      `json:"sessions,omitempty" hash:"ignore"`
+	Totals         map[string]float64 `json:"totals,omitempty" hash:"ignore"`
+	Days           []*MonitoringDay   `json:"days,omitempty" hash:"ignore"`
+	Power          *Power             `json:"power,omitempty" hash:"ignore"`
+	Curves         []*Curve           `json:"curves,omitempty" hash:"ignore"`
+	HeartRateZones []*ZoneTime        `json:"heart_rate_zones,omitempty" hash:"ignore"`
+	Tags           map[string]string  `json:"tags" hash:"ignore"`
+
+	mmap       map[string]*Measurement `json:"-"`
+	timestamps []time.Time             `json:"-"`
+	startPos   *geodist.Coord          `json:"-"`
 }
 
 func (a *Activity) FinalizeMeasurements(measurements []string) []*Measurement {
+	a.Measurements = finalizeMeasurements(a.mmap, measurements, a.Measurements, a.timestamps)
+	return a.Measurements
+}
+
+func finalizeMeasurements(mmap map[string]*Measurement, measurements []string, finalized []*Measurement, timestamps []time.Time) []*Measurement {
 	for _, measurement := range measurements {
-		if v, ok := a.mmap[measurement]; ok {
+		if v, ok := mmap[measurement]; ok {
 			if m, ok := v.Finalize(); ok {
-				a.Measurements = append(a.Measurements, m)
+				m.FinalizeTimeWeighted(timestamps, RecordMaxGap)
+				finalized = append(finalized, m)
 			}
 		}
 	}
 
-	return a.Measurements
+	return finalized
 }
 
 func (s *Activity) CalculateCorrelations(correlates [][2]string) []*Correlation {
@@ -70,6 +91,10 @@ func (s *Activity) CalculateCorrelations(correlates [][2]string) []*Correlation
 }
 
 func (a *Activity) AddValue(key string, value interface{}) {
+	addValue(a.mmap, key, value)
+}
+
+func addValue(mmap map[string]*Measurement, key string, value interface{}) {
 	var val float64
 	switch v := value.(type) {
 	case uint:
@@ -98,7 +123,7 @@ func (a *Activity) AddValue(key string, value interface{}) {
 		val = v
 	}
 
-	m, ok := a.mmap[key]
+	m, ok := mmap[key]
 	if !ok {
 		return
 	}
@@ -113,23 +138,28 @@ func (a *Activity) AddValue(key string, value interface{}) {
 		return
 	}
 
-	m.count += 1
-	m.sum += val
-	if val > m.Maximum {
+	// the first value sets both bounds, as values such as grade may be
+	// negative
+	if m.count == 0 || val > m.Maximum {
 		m.Maximum = val
 	}
-	if val < m.Minimum {
+	if m.count == 0 || val < m.Minimum {
 		m.Minimum = val
 	}
+	m.count += 1
+	m.sum += val
 }
 
-func Summarize(data *fit.File, measures []string, correlates [][2]string, tags map[string]string) (*Activity, error) {
+// Summarize calculates an activity summary from the file. Accumulator
+// options configure measurements derived from multiple records.
+func Summarize(data *fit.File, measures []string, correlates [][2]string, tags map[string]string, options ...AccumulatorOption) (*Activity, error) {
 	switch data.Type() {
 	case fit.FileTypeActivity:
-		fitType, err := Type(data)
+		activityType, err := Type(data)
 		if err != nil {
 			return nil, fmt.Errorf("type: %w", err)
 		}
+		fitType := activityType.Type
 
 		activityData, err := data.Activity()
 		if err != nil {
@@ -138,9 +168,10 @@ func Summarize(data *fit.File, measures []string, correlates [][2]string, tags m
 
 		lastIdx := len(activityData.Records) - 1
 		if lastIdx < 0 {
-			return nil, fmt.Errorf("file contains no records")
+			return nil, ErrNoRecords
 		}
 
+		acc := NewAccumulator(options...)
 		activity := &Activity{
 			Type:         fitType,
 			StartTime:    activityData.Records[0].Timestamp,
@@ -148,34 +179,104 @@ func Summarize(data *fit.File, measures []string, correlates [][2]string, tags m
 			Measurements: make([]*Measurement, 0, 8),
 			Correlations: make([]*Correlation, 0, len(correlates)),
 			Tags:         tags,
-			mmap:         make(map[string]*Measurement),
+			mmap:         acc.registry.measurementMap(fitType),
 		}
 
-		for name, m := range DefaultMeasurements {
-			activity.mmap[name] = NewMeasurement(name, m.Unit, m.Unset)
+		activity.Laps = newLapSummaries(activityData.Laps, activity.Type, options...)
+
+		// only break down multisport activities by session
+		if len(activityData.Sessions) > 1 {
+			activity.Sessions = newSessionSummaries(activityData.Sessions, activity.Type, options...)
 		}
 
-		if activity.Type != TypeMonitoring && activity.Type != TypeTracking {
-			for name, m := range DefaultSportMeasurements {
-				activity.mmap[name] = NewMeasurement(name, m.Unit, m.Unset)
+		var power []sample
+		for _, record := range activityData.Records {
+			acc, err = ReadRecord(acc, record, activity.AddValue)
+			if err != nil {
+				return nil, fmt.Errorf("read record: %w", err)
+			}
+			activity.timestamps = append(activity.timestamps, record.Timestamp)
+
+			if _, ok := activity.mmap["power"]; ok && !acc.registry.IsUnset("power", float64(record.Power)) {
+				power = append(power, sample{record.Timestamp, float64(record.Power)})
+			}
+
+			for _, lap := range activity.Laps {
+				err = lap.ReadRecord(record)
+				if err != nil {
+					return nil, fmt.Errorf("lap %d: read record: %w", lap.Index, err)
+				}
+			}
+			for _, session := range activity.Sessions {
+				err = session.ReadRecord(record)
+				if err != nil {
+					return nil, fmt.Errorf("session %d: read record: %w", session.Index, err)
+				}
 			}
 		}
+		activity.Measurements = activity.FinalizeMeasurements(measures)
+		activity.Correlations = activity.CalculateCorrelations(correlates)
+		activity.Power = calculatePower(power)
+		activity.Totals = acc.Totals()
+		activity.calculatePauses(activityData.Records, activityData.Events)
+		activity.Curves = activity.CalculateCurves(DefaultCurveMeasurements, DefaultCurveDurations)
+		for _, lap := range activity.Laps {
+			lap.FinalizeMeasurements(measures)
+		}
+		for _, session := range activity.Sessions {
+			session.FinalizeMeasurements(measures)
+		}
 
-		if activity.Type == TypeCycling {
-			for name, m := range DefaultCyclingMeasurements {
-				activity.mmap[name] = NewMeasurement(name, m.Unit, m.Unset)
+		return activity, nil
+	case fit.FileTypeMonitoringA, fit.FileTypeMonitoringB, fit.FileTypeMonitoringDaily:
+		msgs, info, err := monitoringData(data)
+		if err != nil {
+			return nil, err
+		}
+
+		// compressed timestamps are not expanded by the decoder, so
+		// messages may contain the FIT base time
+		var start, end time.Time
+		for _, msg := range msgs {
+			if fit.IsBaseTime(msg.Timestamp) {
+				continue
+			}
+			if start.IsZero() || msg.Timestamp.Before(start) {
+				start = msg.Timestamp
+			}
+			if msg.Timestamp.After(end) {
+				end = msg.Timestamp
 			}
 		}
+		if start.IsZero() {
+			return nil, ErrNoRecords
+		}
 
-		acc := new(Accumulator)
-		for _, record := range activityData.Records {
-			acc, err = ReadRecord(acc, record, activity.AddValue)
+		activity := &Activity{
+			Type:         TypeMonitoring,
+			StartTime:    start,
+			EndTime:      end,
+			Measurements: make([]*Measurement, 0, 8),
+			Correlations: make([]*Correlation, 0, len(correlates)),
+			Tags:         tags,
+			mmap:         NewAccumulator(options...).registry.measurementMap(TypeMonitoring),
+		}
+
+		// measurements are calculated from the increase of each counter
+		// over the logging interval, as accumulated values depend on the
+		// time of day
+		acc := NewMonitoringAccumulator()
+		acc.offset = monitoringOffset(info)
+		for _, msg := range msgs {
+			acc, err = readMonitoring(acc, msg, activity.AddValue, true)
 			if err != nil {
-				return nil, fmt.Errorf("read record: %w", err)
+				return nil, fmt.Errorf("read monitoring: %w", err)
 			}
 		}
 		activity.Measurements = activity.FinalizeMeasurements(measures)
 		activity.Correlations = activity.CalculateCorrelations(correlates)
+		activity.Totals = acc.Totals()
+		activity.Days = acc.Days()
 
 		return activity, nil
 	}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	fitcmd "github.com/subtlepseudonym/fit"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// measurementConfig is the format of the measurement config file, which
+// registers additional record fields as measurements. JSON files are also
+// accepted.
+//
+//	measurements:
+//	  - name: left_right_balance
+//	    unit: percent
+//	    unset: 255
+//	    field: LeftRightBalance
+//	    types: [cycle]
+type measurementConfig struct {
+	Measurements []struct {
+		Name         string   `yaml:"name"`
+		Unit         string   `yaml:"unit"`
+		Unset        uint     `yaml:"unset"`
+		Field        string   `yaml:"field"`
+		Types        []string `yaml:"types"`
+		ExcludeTypes []string `yaml:"exclude_types"`
+	} `yaml:"measurements"`
+}
+
+// loadConfig loads the config files provided by flags
+func loadConfig(cmd *cobra.Command, args []string) error {
+	filename, _ := cmd.Flags().GetString("measurement-config")
+	if filename != "" {
+		err := loadMeasurementConfig(filename)
+		if err != nil {
+			return err
+		}
+	}
+
+	filename, _ = cmd.Flags().GetString("type-config")
+	if filename != "" {
+		err := loadTypeConfig(filename)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadTypeConfig adds the rules in the type mapping file to the default
+// type mapping. JSON files are also accepted.
+//
+//	names:
+//	  Gravel: cycle
+//	sub_sports:
+//	  IndoorRowing: row
+//	sports:
+//	  Paddling: paddle
+func loadTypeConfig(filename string) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("read type config: %w", err)
+	}
+
+	var mapping fitcmd.TypeMapping
+	err = yaml.Unmarshal(b, &mapping)
+	if err != nil {
+		return fmt.Errorf("unmarshal type config: %w", err)
+	}
+
+	fitcmd.DefaultTypeMapping.Merge(&mapping)
+	return nil
+}
+
+// loadMeasurementConfig registers the measurements in the config file and
+// includes them in summaries
+func loadMeasurementConfig(filename string) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("read measurement config: %w", err)
+	}
+
+	var config measurementConfig
+	err = yaml.Unmarshal(b, &config)
+	if err != nil {
+		return fmt.Errorf("unmarshal measurement config: %w", err)
+	}
+
+	for _, m := range config.Measurements {
+		extract, err := fitcmd.FieldExtractor(m.Field)
+		if err != nil {
+			return fmt.Errorf("measurement %q: %w", m.Name, err)
+		}
+
+		err = fitcmd.DefaultRegistry.Register(fitcmd.MeasurementDefinition{
+			Name:         m.Name,
+			Unit:         m.Unit,
+			Unset:        m.Unset,
+			Types:        m.Types,
+			ExcludeTypes: m.ExcludeTypes,
+			Extract:      extract,
+		})
+		if err != nil {
+			return fmt.Errorf("register measurement: %w", err)
+		}
+
+		if !contains(DefaultMeasurements, m.Name) {
+			DefaultMeasurements = append(DefaultMeasurements, m.Name)
+		}
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	fitcmd "github.com/subtlepseudonym/fit"
+
+	"github.com/spf13/cobra"
+	fit "github.com/subtlepseudonym/fit-go"
+)
+
+func NewCSVCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "csv",
+		Short: "Convert fit file records to CSV",
+		RunE:  csv,
+	}
+
+	cmd.Flags().StringSlice("columns", nil, "Columns to write, from timestamp and measurement names (default all measurements)")
+	cmd.Flags().Bool("tsv", false, "Write tab separated values")
+	addElevationFlags(cmd.Flags())
+
+	return cmd
+}
+
+func csv(cmd *cobra.Command, args []string) error {
+	columns, _ := cmd.Flags().GetStringSlice("columns")
+	elevation, err := elevationFromFlags(cmd.Flags())
+	if err != nil {
+		return err
+	}
+
+	ext, comma := "csv", ','
+	if tsv, _ := cmd.Flags().GetBool("tsv"); tsv {
+		ext, comma = "tsv", '\t'
+	}
+
+	for _, arg := range args {
+		file, err := os.Open(arg)
+		if err != nil {
+			return fmt.Errorf("open: %w", err)
+		}
+		defer file.Close()
+
+		data, err := fit.Decode(file)
+		if err != nil {
+			ignore, _ := cmd.Flags().GetBool("ignore-file-checksum")
+			_, ok := err.(fit.IntegrityError)
+			if !ignore || !ok {
+				return fmt.Errorf("decode: %w", err)
+			}
+		}
+
+		csvFile := fmt.Sprintf("%s.%s", strings.TrimSuffix(path.Base(file.Name()), path.Ext(file.Name())), ext)
+		output, err := os.Create(csvFile)
+		if err != nil {
+			return fmt.Errorf("open: %w", err)
+		}
+		defer output.Close()
+
+		err = fitcmd.WriteCSV(output, data, columns, comma, elevation)
+		if err != nil {
+			return fmt.Errorf("write %s: %w", ext, err)
+		}
+	}
+
+	return nil
+}
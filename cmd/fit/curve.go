@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	fitcmd "github.com/subtlepseudonym/fit"
+
+	"github.com/spf13/cobra"
+	fit "github.com/subtlepseudonym/fit-go"
+)
+
+func NewCurveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "curve",
+		Short: "Display best effort power, heart rate, and speed curves",
+		RunE:  curve,
+	}
+}
+
+func curve(cmd *cobra.Command, args []string) error {
+	for _, arg := range args {
+		file, err := os.Open(arg)
+		if err != nil {
+			return fmt.Errorf("open: %w", err)
+		}
+		defer file.Close()
+
+		data, err := fit.Decode(file)
+		if err != nil {
+			ignore, _ := cmd.Flags().GetBool("ignore-file-checksum")
+			_, ok := err.(fit.IntegrityError)
+			if !ignore || !ok {
+				return fmt.Errorf("decode: %w", err)
+			}
+		}
+
+		activity, err := fitcmd.Summarize(data, DefaultMeasurements, nil, nil)
+		if err != nil {
+			return fmt.Errorf("summarize: %w", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "MEASUREMENT\tDURATION\tVALUE\tUNIT")
+		for _, c := range activity.Curves {
+			for _, point := range c.Points {
+				duration := time.Duration(point.Duration) * time.Second
+				fmt.Fprintf(w, "%s\t%s\t%.2f\t%s\n", c.Measurement, duration, point.Value, c.Unit)
+			}
+		}
+
+		err = w.Flush()
+		if err != nil {
+			return fmt.Errorf("flush: %w", err)
+		}
+	}
+
+	return nil
+}
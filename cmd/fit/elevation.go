@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+
+	fitcmd "github.com/subtlepseudonym/fit"
+
+	"github.com/spf13/pflag"
+)
+
+// addElevationFlags adds flags for configuring altitude smoothing
+func addElevationFlags(flags *pflag.FlagSet) {
+	flags.Float64("altitude-hysteresis", fitcmd.DefaultAltitudeHysteresis, "Altitude change in meters required before counting ascent or descent")
+}
+
+// elevationFromFlags returns the accumulator option configuring altitude
+// smoothing for summarizing and writing records
+func elevationFromFlags(flags *pflag.FlagSet) (fitcmd.AccumulatorOption, error) {
+	hysteresis, err := flags.GetFloat64("altitude-hysteresis")
+	if err != nil {
+		return nil, fmt.Errorf("altitude hysteresis flag: %w", err)
+	}
+	if hysteresis < 0 {
+		return nil, fmt.Errorf("altitude hysteresis must not be negative: %v", hysteresis)
+	}
+
+	return fitcmd.WithHysteresis(hysteresis), nil
+}
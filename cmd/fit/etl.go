@@ -2,19 +2,19 @@ package main
 
 import (
 	"bytes"
-	"context"
-	"database/sql"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"time"
 
 	fitcmd "github.com/subtlepseudonym/fit"
 
-	"github.com/influxdata/influxdb-client-go/v2"
-	"github.com/influxdata/influxdb-client-go/v2/api"
-	_ "github.com/lib/pq"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	fit "github.com/subtlepseudonym/fit-go"
 )
 
@@ -25,175 +25,317 @@ func NewETLCommand() *cobra.Command {
 		RunE:  etlAll,
 	}
 
-	// non-persistent
 	flags := cmd.Flags()
+	addETLFlags(flags)
+	flags.Bool("remove-after-import", false, "Remove source files once successfully imported")
+	flags.String("watch", "", "Directory to watch for new files, running until interrupted")
+	flags.Duration("watch-interval", 2*time.Second, "Polling interval when directory events are unavailable")
+	flags.Duration("watch-delay", 5*time.Second, "Time without new files before a batch is imported")
+	addStorageFlags(cmd.PersistentFlags())
+
+	cmd.AddCommand(NewETLMigrateCommand())
+	cmd.AddCommand(NewETLSetupCommand())
+
+	return cmd
+}
+
+// addETLFlags adds flags that configure how files are extracted and loaded
+func addETLFlags(flags *pflag.FlagSet) {
 	flags.Bool("verbose", false, "Print additional information")
 	flags.String("device", DefaultDevice, "Telemetry device name")
+	flags.Int("concurrency", 1, "Number of files to decode and summarize in parallel")
+	flags.Bool("force", false, "Import files that have previously been imported")
+	flags.Float64("ftp", 0, "Functional threshold power in watts for calculating training load")
+	flags.Bool("zone-tag", false, "Tag line protocol records with heart rate zone")
+	addZoneFlags(flags)
+	addElevationFlags(flags)
+}
 
-	persistent := cmd.PersistentFlags()
-	persistent.String("postgres", "", "Postgres DSN")
-	persistent.String("postgres-import-table", "import", "Table name for import run information")
-	persistent.String("postgres-activity-table", "activity", "Table name for activity records")
-	persistent.String("postgres-measurement-table", "measurement", "Table name for per-activity measurement records")
-	persistent.String("postgres-correlation-table", "correlation", "Table for measurement correlation records")
-	persistent.String("influx-host", "", "InfluxDB DSN")
-	persistent.String("influx-token", "", "InfluxDB API token")
-	persistent.String("influx-org", "default", "InfluxDB organization")
-	persistent.String("influx-bucket", "fit", "InfluxDB bucket")
-
-	cmd.MarkPersistentFlagRequired("postgres")
-	cmd.MarkFlagRequired("influx-host")
-	cmd.MarkFlagRequired("influx-token")
+// addStorageFlags adds flags that configure downstream storage
+func addStorageFlags(flags *pflag.FlagSet) {
+	flags.String("postgres", "", "Postgres DSN")
+	flags.String("postgres-import-table", "import", "Table name for import run information")
+	flags.String("postgres-activity-table", "activity", "Table name for activity records")
+	flags.String("postgres-measurement-table", "measurement", "Table name for per-activity measurement records")
+	flags.String("postgres-correlation-table", "correlation", "Table for measurement correlation records")
+	flags.String("postgres-lap-table", "lap", "Table name for per-activity lap records")
+	flags.String("postgres-lap-measurement-table", "lap_measurement", "Table name for per-lap measurement records")
+	flags.String("postgres-import-file-table", "import_file", "Table name for imported file checksums")
+	flags.String("postgres-curve-table", "curve", "Table name for per-activity best effort curves")
+	flags.String("postgres-heart-rate-zone-table", "heart_rate_zone", "Table name for per-activity time in heart rate zones")
+	flags.String("postgres-schema-version-table", "schema_version", "Table name for applied schema migrations")
+	flags.String("influx-host", "", "InfluxDB DSN")
+	flags.String("influx-token", "", "InfluxDB API token")
+	flags.String("influx-org", "default", "InfluxDB organization")
+	flags.String("influx-bucket", "fit", "InfluxDB bucket")
+	flags.String("sqlite", "", "SQLite database path, used instead of postgres and influx unless their flags are set")
+	flags.Bool("no-postgres", false, "Disable writing summaries to postgres")
+	flags.Bool("no-influx", false, "Disable writing records to influx")
+}
 
-	cmd.AddCommand(NewETLSetupCommand())
+func etlAll(cmd *cobra.Command, args []string) error {
+	p, err := newPipeline(cmd)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
 
-	return cmd
+	// files provided as arguments are imported before watching
+	if dir, _ := cmd.Flags().GetString("watch"); dir != "" {
+		if len(args) > 0 {
+			_, err = p.Run(args)
+			if err != nil {
+				return err
+			}
+		}
+		return etlWatch(p, dir)
+	}
+
+	_, err = p.Run(args)
+	return err
 }
 
-func etlAll(cmd *cobra.Command, args []string) error {
-	// set up postgres client
+// pipeline extracts files and loads them into downstream storage, recording
+// each run in the import table
+type pipeline struct {
+	cmd     *cobra.Command
+	imports storage
+	sinks   []fitcmd.Sink
+	device  string
+	tags    map[string]string
+}
+
+func newPipeline(cmd *cobra.Command) (*pipeline, error) {
 	flags := cmd.Flags()
-	postgresDSN, _ := flags.GetString("postgres")
-	db, err := sql.Open("postgres", postgresDSN)
+	device, err := flags.GetString("device")
 	if err != nil {
-		return fmt.Errorf("sql open: %w", err)
+		return nil, fmt.Errorf("device flag: %w", err)
 	}
-	defer db.Close()
 
-	device, err := cmd.Flags().GetString("device")
+	_, err = elevationFromFlags(flags)
 	if err != nil {
-		return fmt.Errorf("device flag: %w", err)
+		return nil, err
 	}
 
-	// set up influx client
-	influxHost, _ := flags.GetString("influx-host")
-	influxToken, _ := flags.GetString("influx-token")
-	influxOrg, _ := flags.GetString("influx-org")
-	influxBucket, _ := flags.GetString("influx-bucket")
-
-	options := influxdb2.DefaultOptions()
-	options.SetPrecision(time.Second)
-
-	client := influxdb2.NewClientWithOptions(influxHost, influxToken, options)
-	defer client.Close()
-	influxAPI := client.WriteAPIBlocking(influxOrg, influxBucket)
+	imports, sinks, err := newSinks(flags)
+	if err != nil {
+		return nil, err
+	}
 
 	tags := map[string]string{
 		"device": device,
 	}
 
-	if ignore, _ := cmd.Flags().GetBool("ignore-file-checksum"); ignore {
+	if ignore, _ := flags.GetBool("ignore-file-checksum"); ignore {
 		tags["ignore-file-checksum"] = "true"
 	}
 
-	importTable, _ := flags.GetString("postgres-import-table")
-	importID, err := insertImport(db, importTable, time.Now(), device)
-	if err != nil {
-		return fmt.Errorf("insert import record: %w", err)
-	}
+	return &pipeline{
+		cmd:     cmd,
+		imports: imports,
+		sinks:   sinks,
+		device:  device,
+		tags:    tags,
+	}, nil
+}
 
-	var files []string
-	var errors []string
-	verbose, _ := flags.GetBool("verbose")
-	for _, arg := range args {
-		files = append(files, path.Base(arg))
-		err = etl(cmd, db, influxAPI, arg, importID, tags)
+func (p *pipeline) Close() {
+	for _, sink := range p.sinks {
+		err := sink.Close()
 		if err != nil {
-			errors = append(errors, fmt.Sprintf("etl: %s: %s", arg, err))
-		}
-		if verbose {
-			fmt.Println(path.Base(arg))
+			fmt.Println("ERR: failed to close sink:", err)
 		}
 	}
+}
+
+// Run extracts and loads the provided files as a single import, returning
+// the import ID
+func (p *pipeline) Run(args []string) (string, error) {
+	flags := p.cmd.Flags()
 
-	err = updateImport(db, importTable, importID, time.Now(), files, errors)
+	importID, err := p.imports.InsertImport(time.Now(), p.device)
 	if err != nil {
-		return fmt.Errorf("update import record: %s: %w", importID, err)
-	}
-	if verbose {
-		fmt.Println("import ID:", importID)
+		return "", fmt.Errorf("insert import record: %w", err)
 	}
 
-	return nil
-}
+	concurrency, _ := flags.GetInt("concurrency")
+	if concurrency < 1 {
+		concurrency = 1
+	}
 
-func etl(cmd *cobra.Command, db *sql.DB, influxAPI api.WriteAPIBlocking, filename, importID string, tags map[string]string) (ret error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return fmt.Errorf("open: %w", err)
+	// files are extracted concurrently, but loaded in argument order so
+	// that files and errors are recorded deterministically
+	results := make([]chan *extractedFile, len(args))
+	for i := range results {
+		results[i] = make(chan *extractedFile, 1)
 	}
-	defer file.Close()
 
-	data, err := fit.Decode(file)
-	if err != nil {
-		ignore, _ := cmd.Flags().GetBool("ignore-file-checksum")
-		_, ok := err.(fit.IntegrityError)
-		if !ignore || !ok {
-			return fmt.Errorf("decode: %w", err)
+	// limit the number of extracted files waiting to be loaded
+	pending := make(chan struct{}, 2*concurrency)
+	indices := make(chan int)
+	go func() {
+		for i := range args {
+			pending <- struct{}{}
+			indices <- i
 		}
-	}
+		close(indices)
+	}()
 
-	activity, err := fitcmd.Summarize(data, DefaultMeasurements, DefaultCorrelates, tags)
-	if err != nil {
-		return fmt.Errorf("summarize: %w", err)
+	force, _ := flags.GetBool("force")
+	for n := 0; n < concurrency; n++ {
+		go func() {
+			for i := range indices {
+				e, err := extract(p.cmd, p.imports, args[i], p.tags, force)
+				if err != nil {
+					e = &extractedFile{filename: args[i], err: err}
+				}
+				results[i] <- e
+			}
+		}()
 	}
 
-	tx, err := db.Begin()
-	if err != nil {
-		return fmt.Errorf("begin sql transaction: %w", err)
+	verbose, _ := flags.GetBool("verbose")
+	remove, _ := flags.GetBool("remove-after-import")
+
+	// log is written to the import record and, if verbose, to stdout
+	logBuf := new(bytes.Buffer)
+	logOut := io.Writer(logBuf)
+	if verbose {
+		logOut = io.MultiWriter(logBuf, os.Stdout)
 	}
-	defer func() {
-		if ret != nil {
-			err := tx.Rollback()
+
+	record := importRecord{ID: importID}
+	for i, arg := range args {
+		e := <-results[i]
+		<-pending
+
+		filename := path.Base(arg)
+		for _, warning := range e.warnings {
+			fmt.Fprintf(logOut, "WARN: %s: %s\n", filename, warning)
+		}
+
+		var imported bool
+		if e.skipped {
+			record.Skipped = append(record.Skipped, filename)
+			fmt.Fprintln(logOut, "skipped", filename)
+			imported = true
+		} else {
+			record.Files = append(record.Files, filename)
+			source := fitcmd.Source{
+				ImportID: importID,
+				Checksum: e.checksum,
+				Filename: filename,
+			}
+
+			err = e.err
+			if err == nil && e.activity != nil {
+				err = fitcmd.Load(p.sinks, e.data, e.activity, source)
+				imported = err == nil
+			} else if err == nil {
+				// files without records are recorded so that they are
+				// not decoded again
+				err = p.imports.RecordFile(source)
+				imported = err == nil
+			}
 			if err != nil {
-				fmt.Println("ERR: failed to rollback transaction:", err)
+				record.Errors = append(record.Errors, fmt.Sprintf("etl: %s: %s", arg, err))
 			}
+			fmt.Fprintln(logOut, filename)
 		}
-	}()
 
-	flags := cmd.Flags()
-	activityTable, _ := flags.GetString("postgres-activity-table")
-	measurementTable, _ := flags.GetString("postgres-measurement-table")
-	correlationTable, _ := flags.GetString("postgres-correlation-table")
+		if remove && imported {
+			err = os.Remove(arg)
+			if err != nil {
+				fmt.Fprintf(logOut, "WARN: %s: remove: %s\n", filename, err)
+			} else {
+				record.Removed = append(record.Removed, filename)
+				fmt.Fprintln(logOut, "removed", filename)
+			}
+		}
+	}
 
-	activityQuery, err := buildActivityQuery(activityTable, activity, importID)
+	fmt.Fprintln(logOut, "import ID:", importID)
+	record.End = time.Now()
+	record.Log = logBuf.String()
+	err = p.imports.UpdateImport(record)
 	if err != nil {
-		return fmt.Errorf("build activity query: %w", err)
+		return importID, fmt.Errorf("update import record: %s: %w", importID, err)
 	}
 
-	var activityID string
-	err = tx.QueryRow(activityQuery).Scan(&activityID)
+	return importID, nil
+}
+
+// extractedFile holds the decoded and summarized contents of a single file
+type extractedFile struct {
+	filename string
+	checksum string
+	skipped  bool
+	warnings []string
+	data     *fit.File
+	activity *fitcmd.Activity
+	err      error
+}
+
+// extract decodes and summarizes the file. Files that have previously been
+// imported are skipped unless force is set. It is safe to call concurrently.
+func extract(cmd *cobra.Command, s storage, filename string, tags map[string]string, force bool) (*extractedFile, error) {
+	b, err := os.ReadFile(filename)
 	if err != nil {
-		return fmt.Errorf("insert activity: %w", err)
+		return nil, fmt.Errorf("read: %w", err)
 	}
 
-	queries, err := buildQueries(measurementTable, correlationTable, activityID, activity)
-	if err != nil {
-		return fmt.Errorf("build measurement and correlation queries: %w", err)
+	sum := sha256.Sum256(b)
+	e := &extractedFile{
+		filename: filename,
+		checksum: hex.EncodeToString(sum[:]),
 	}
 
-	for _, query := range queries {
-		_, err = tx.Exec(query)
+	if !force {
+		imported, err := s.FileImported(e.checksum)
 		if err != nil {
-			return fmt.Errorf("insert query: %w", err)
+			return nil, fmt.Errorf("select imported file: %w", err)
+		}
+		if imported {
+			e.skipped = true
+			return e, nil
 		}
 	}
 
-	buf := new(bytes.Buffer)
-	err = fitcmd.WriteLineProtocol(buf, data, tags)
+	data, err := fit.Decode(bytes.NewReader(b))
 	if err != nil {
-		return fmt.Errorf("write line protocol: %w", err)
+		ignore, _ := cmd.Flags().GetBool("ignore-file-checksum")
+		_, ok := err.(fit.IntegrityError)
+		if !ignore || !ok {
+			return nil, fmt.Errorf("decode: %w", err)
+		}
+		e.warnings = append(e.warnings, fmt.Sprintf("ignored file checksum: %s", err))
 	}
 
-	err = influxAPI.WriteRecord(context.Background(), buf.String())
+	elevation, err := elevationFromFlags(cmd.Flags())
 	if err != nil {
-		return fmt.Errorf("write influx records: %w", err)
+		return nil, err
+	}
+
+	activity, err := fitcmd.Summarize(data, DefaultMeasurements, DefaultCorrelates, tags, elevation)
+	if errors.Is(err, fitcmd.ErrNoRecords) {
+		e.warnings = append(e.warnings, err.Error())
+		return e, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("summarize: %w", err)
+	}
+
+	ftp, _ := cmd.Flags().GetFloat64("ftp")
+	if activity.Power != nil {
+		activity.Power.CalculateTrainingLoad(ftp)
 	}
 
-	err = tx.Commit()
+	zones, err := zonesFromFlags(cmd.Flags())
 	if err != nil {
-		return fmt.Errorf("commit sql: %w", err)
+		return nil, err
 	}
+	activity.HeartRateZones = activity.CalculateTimeInZones("heart_rate", zones)
 
-	return nil
+	e.data = data
+	e.activity = activity
+	return e, nil
 }
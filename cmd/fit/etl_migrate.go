@@ -0,0 +1,141 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/spf13/cobra"
+)
+
+func NewETLMigrateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage postgres schema version",
+	}
+
+	up := &cobra.Command{
+		Use:   "up",
+		Short: "Apply pending schema migrations",
+		RunE:  etlMigrateUp,
+	}
+	up.Flags().Int("to", math.MaxInt32, "Schema version to migrate up to (default latest)")
+
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "Revert applied schema migrations",
+		RunE:  etlMigrateDown,
+	}
+	down.Flags().Int("to", -1, "Schema version to migrate down to (default previous version)")
+
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "Display applied and pending schema migrations",
+		RunE:  etlMigrateStatus,
+	}
+
+	cmd.AddCommand(up)
+	cmd.AddCommand(down)
+	cmd.AddCommand(status)
+
+	return cmd
+}
+
+func openMigrationDB(cmd *cobra.Command) (*sql.DB, tables, error) {
+	flags := cmd.Flags()
+	postgresDSN, _ := flags.GetString("postgres")
+	if postgresDSN == "" {
+		return nil, tables{}, errors.New("required flag \"postgres\" not set")
+	}
+
+	t, err := tablesFromFlags(flags)
+	if err != nil {
+		return nil, t, err
+	}
+
+	db, err := sql.Open("postgres", postgresDSN)
+	if err != nil {
+		return nil, t, fmt.Errorf("sql open: %w", err)
+	}
+
+	return db, t, nil
+}
+
+func etlMigrateUp(cmd *cobra.Command, args []string) error {
+	db, t, err := openMigrationDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	target, _ := cmd.Flags().GetInt("to")
+	return migrateUp(db, t, target, func(m *migration) {
+		fmt.Printf("applied %d_%s\n", m.Version, m.Name)
+	})
+}
+
+func etlMigrateDown(cmd *cobra.Command, args []string) error {
+	db, t, err := openMigrationDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	target, _ := cmd.Flags().GetInt("to")
+	if target < 0 {
+		applied, err := appliedMigrations(db, t.SchemaVersion)
+		if err != nil {
+			return err
+		}
+
+		migrations, err := loadMigrations(t)
+		if err != nil {
+			return err
+		}
+
+		// revert only the most recently applied migration
+		current := schemaVersion(applied)
+		target = 0
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok && m.Version < current {
+				target = m.Version
+			}
+		}
+	}
+
+	return migrateDown(db, t, target, func(m *migration) {
+		fmt.Printf("reverted %d_%s\n", m.Version, m.Name)
+	})
+}
+
+func etlMigrateStatus(cmd *cobra.Command, args []string) error {
+	db, t, err := openMigrationDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrations, err := loadMigrations(t)
+	if err != nil {
+		return err
+	}
+
+	applied, err := appliedMigrations(db, t.SchemaVersion)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("schema version:", schemaVersion(applied))
+	for _, m := range migrations {
+		if appliedAt, ok := applied[m.Version]; ok {
+			fmt.Printf("%04d_%s\tapplied %s\n", m.Version, m.Name, appliedAt.Format(time.RFC3339))
+		} else {
+			fmt.Printf("%04d_%s\tpending\n", m.Version, m.Name)
+		}
+	}
+
+	return nil
+}
@@ -2,8 +2,8 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/influxdata/influxdb-client-go/v2"
@@ -18,59 +18,68 @@ func NewETLSetupCommand() *cobra.Command {
 		RunE:  etlSetup,
 	}
 
-	cmd.Flags().Bool("no-influx", false, "Exclude influxdb from setup")
-
 	return cmd
 }
 
-func etlSetup(cmd *cobra.Command, args []string) (ret error) {
+// etlSetup sets up the databases that 'etl' writes to with the same flags
+func etlSetup(cmd *cobra.Command, args []string) error {
 	flags := cmd.Flags()
-	postgresDSN, _ := flags.GetString("postgres")
-	importTable, _ := flags.GetString("postgres-import-table")
-	activityTable, _ := flags.GetString("postgres-activity-table")
-	measurementTable, _ := flags.GetString("postgres-measurement-table")
-	correlationTable, _ := flags.GetString("postgres-correlation-table")
-
-	db, err := sql.Open("postgres", postgresDSN)
+	usePostgres, useInflux, err := enabledSinks(flags)
 	if err != nil {
-		return fmt.Errorf("sql open: %w", err)
+		return err
 	}
-	defer db.Close()
 
-	tx, err := db.Begin()
-	if err != nil {
-		return fmt.Errorf("begin sql transaction: %w", err)
-	}
-	defer func() {
-		if ret != nil {
-			err := tx.Rollback()
-			if err != nil {
-				fmt.Println("ERR: failed to rollback transaction:", err)
-			}
+	if usePostgres {
+		err = setupPostgres(cmd)
+		if err != nil {
+			return err
 		}
-	}()
+	}
 
-	setupQuery := buildSetupQuery(importTable, activityTable, measurementTable, correlationTable)
-	_, err = tx.Exec(setupQuery)
-	if err != nil {
-		return fmt.Errorf("setup query: %w", err)
+	if sqlitePath, _ := flags.GetString("sqlite"); sqlitePath != "" {
+		err = setupSQLite(sqlitePath)
+		if err != nil {
+			return err
+		}
 	}
 
-	if noInflux, _ := flags.GetBool("no-influx"); !noInflux {
+	if useInflux {
 		err = setupInflux(cmd, args)
 		if err != nil {
 			return err
 		}
 	}
 
-	err = tx.Commit()
+	return nil
+}
+
+func setupPostgres(cmd *cobra.Command) error {
+	db, t, err := openMigrationDB(cmd)
 	if err != nil {
-		return fmt.Errorf("commit sql: %w", err)
+		return err
+	}
+	defer db.Close()
+
+	// migrations are idempotent, so setup may be safely run against an
+	// existing database
+	err = migrateUp(db, t, math.MaxInt32, nil)
+	if err != nil {
+		return fmt.Errorf("setup postgres: %w", err)
 	}
 
 	return nil
 }
 
+// setupSQLite creates the sqlite database and its tables if they do not
+// exist
+func setupSQLite(filename string) error {
+	s, err := openSQLiteStorage(filename)
+	if err != nil {
+		return fmt.Errorf("setup sqlite: %w", err)
+	}
+	return s.Close()
+}
+
 func setupInflux(cmd *cobra.Command, args []string) error {
 	flags := cmd.Flags()
 	influxHost, _ := flags.GetString("influx-host")
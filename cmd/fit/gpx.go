@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	fitcmd "github.com/subtlepseudonym/fit"
+
+	"github.com/spf13/cobra"
+	fit "github.com/subtlepseudonym/fit-go"
+)
+
+func NewGPXCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "gpx",
+		Short: "Convert fit file to GPX track",
+		RunE:  gpx,
+	}
+}
+
+func gpx(cmd *cobra.Command, args []string) error {
+	for _, arg := range args {
+		file, err := os.Open(arg)
+		if err != nil {
+			return fmt.Errorf("open: %w", err)
+		}
+		defer file.Close()
+
+		data, err := fit.Decode(file)
+		if err != nil {
+			ignore, _ := cmd.Flags().GetBool("ignore-file-checksum")
+			_, ok := err.(fit.IntegrityError)
+			if !ignore || !ok {
+				return fmt.Errorf("decode: %w", err)
+			}
+		}
+
+		gpxFile := fmt.Sprintf("%s.gpx", strings.TrimSuffix(path.Base(file.Name()), path.Ext(file.Name())))
+		output, err := os.Create(gpxFile)
+		if err != nil {
+			return fmt.Errorf("open: %w", err)
+		}
+		defer output.Close()
+
+		err = fitcmd.WriteGPX(output, data)
+		if err != nil {
+			return fmt.Errorf("write gpx: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// deviceFolders are the Garmin device folders that FIT files are imported
+// from, relative to the device root or its GARMIN directory
+var deviceFolders = []string{
+	"Activity",
+	"Monitor",
+}
+
+func NewImportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import DEVICE_DIR",
+		Short: "Archive new files from a device and ETL them into downstream storage",
+		Args:  cobra.ExactArgs(1),
+		RunE:  importDevice,
+	}
+
+	flags := cmd.Flags()
+	flags.String("archive", "", "Directory to copy device files into")
+	flags.Duration("timeout", 60*time.Second, "Maximum time to wait for device directory")
+	flags.Duration("poll-interval", time.Second, "Interval between checks for device directory")
+	addETLFlags(flags)
+	addStorageFlags(flags)
+
+	cmd.MarkFlagRequired("archive")
+
+	return cmd
+}
+
+func importDevice(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	archive, _ := flags.GetString("archive")
+	timeout, _ := flags.GetDuration("timeout")
+	interval, _ := flags.GetDuration("poll-interval")
+	verbose, _ := flags.GetBool("verbose")
+
+	// the pipeline is built before archiving so that flag and storage
+	// errors don't leave archived files that are never imported
+	p, err := newPipeline(cmd)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	deviceDir := args[0]
+	err = waitForDir(deviceDir, timeout, interval)
+	if err != nil {
+		return err
+	}
+
+	var copied int
+	var files []string
+	for _, folder := range deviceFolders {
+		dst := filepath.Join(archive, folder)
+		if src, ok := findDeviceFolder(deviceDir, folder); ok {
+			archived, err := archiveFolder(src, dst)
+			if err != nil {
+				return fmt.Errorf("archive %s: %w", folder, err)
+			}
+			copied += len(archived)
+		}
+
+		// every archived file is passed to the pipeline, as files archived
+		// by a failed run may not have been imported; previously imported
+		// files are skipped by checksum
+		archived, err := listFitFiles(dst)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("list archived %s: %w", folder, err)
+		}
+		files = append(files, archived...)
+	}
+
+	if verbose {
+		fmt.Println("archived", copied, "new files")
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	_, err = p.Run(files)
+	return err
+}
+
+// waitForDir polls until the directory exists and contains at least one
+// entry or the timeout is exceeded
+func waitForDir(dir string, timeout, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		entries, err := os.ReadDir(dir)
+		if err == nil && len(entries) > 0 {
+			return nil
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("read device directory: %w", err)
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			if err != nil {
+				return fmt.Errorf("timed out waiting for device directory: %w", err)
+			}
+			return fmt.Errorf("timed out waiting for device directory: no files in %s", dir)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// findDeviceFolder returns the path of the named folder in either the
+// device root or the GARMIN directory
+func findDeviceFolder(deviceDir, folder string) (string, bool) {
+	for _, dir := range []string{
+		filepath.Join(deviceDir, "GARMIN", folder),
+		filepath.Join(deviceDir, folder),
+	} {
+		info, err := os.Stat(dir)
+		if err == nil && info.IsDir() {
+			return dir, true
+		}
+	}
+
+	return "", false
+}
+
+// archiveFolder copies FIT files from src into dst if they are not already
+// present with identical contents, returning the archived paths
+func archiveFolder(src, dst string) ([]string, error) {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return nil, fmt.Errorf("read directory: %w", err)
+	}
+
+	err = os.MkdirAll(dst, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("create archive directory: %w", err)
+	}
+
+	var copied []string
+	for _, entry := range entries {
+		if entry.IsDir() || !isFitFile(entry.Name()) {
+			continue
+		}
+
+		srcFile := filepath.Join(src, entry.Name())
+		dstFile := filepath.Join(dst, entry.Name())
+		ok, err := copyIfChanged(srcFile, dstFile)
+		if err != nil {
+			return copied, fmt.Errorf("copy %s: %w", entry.Name(), err)
+		}
+		if ok {
+			copied = append(copied, dstFile)
+		}
+	}
+
+	return copied, nil
+}
+
+// copyIfChanged copies src to dst unless dst exists with the same contents,
+// returning whether a copy was made
+func copyIfChanged(src, dst string) (bool, error) {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return false, fmt.Errorf("read: %w", err)
+	}
+
+	existing, err := os.ReadFile(dst)
+	if err == nil && sha256.Sum256(existing) == sha256.Sum256(b) {
+		return false, nil
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("read archived: %w", err)
+	}
+
+	// write to a temporary file so that partially copied files are never
+	// left in the archive
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return false, fmt.Errorf("create temp: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(b)
+	if err != nil {
+		tmp.Close()
+		return false, fmt.Errorf("write: %w", err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return false, fmt.Errorf("close: %w", err)
+	}
+
+	err = os.Chmod(tmp.Name(), 0644)
+	if err != nil {
+		return false, fmt.Errorf("chmod: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), dst)
+	if err != nil {
+		return false, fmt.Errorf("rename: %w", err)
+	}
+
+	return true, nil
+}
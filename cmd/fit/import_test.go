@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fit "github.com/subtlepseudonym/fit-go"
+)
+
+// writeEmptyActivity writes an activity file without records to each path,
+// returning its checksum
+func writeEmptyActivity(t *testing.T, paths ...string) string {
+	t.Helper()
+
+	file, err := fit.NewFile(fit.FileTypeActivity, fit.NewHeader(fit.V20, false))
+	if err != nil {
+		t.Fatalf("new file: %s", err)
+	}
+	buf := new(bytes.Buffer)
+	err = fit.Encode(buf, file, binary.LittleEndian)
+	if err != nil {
+		t.Fatalf("encode: %s", err)
+	}
+
+	for _, path := range paths {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatalf("mkdir: %s", err)
+		}
+		err = os.WriteFile(path, buf.Bytes(), 0644)
+		if err != nil {
+			t.Fatalf("write: %s", err)
+		}
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+func TestImportDeviceImportsArchivedFiles(t *testing.T) {
+	dir := t.TempDir()
+	device := filepath.Join(dir, "device")
+	archive := filepath.Join(dir, "archive")
+	db := filepath.Join(dir, "fit.db")
+
+	// the file was archived by a previous run that failed before importing
+	checksum := writeEmptyActivity(t,
+		filepath.Join(device, "GARMIN", "Activity", "activity.fit"),
+		filepath.Join(archive, "Activity", "activity.fit"),
+	)
+
+	cmd := NewImportCommand()
+	err := cmd.ParseFlags([]string{"--archive", archive, "--sqlite", db, "--timeout", "0"})
+	if err != nil {
+		t.Fatalf("parse flags: %s", err)
+	}
+	err = importDevice(cmd, []string{device})
+	if err != nil {
+		t.Fatalf("import: %s", err)
+	}
+
+	s, err := openSQLiteStorage(db)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+	defer s.Close()
+
+	imported, err := s.FileImported(checksum)
+	if err != nil {
+		t.Fatalf("file imported: %s", err)
+	}
+	if !imported {
+		t.Error("archived file not imported")
+	}
+}
+
+func TestImportDeviceInvalidFlagsDoNotArchive(t *testing.T) {
+	dir := t.TempDir()
+	device := filepath.Join(dir, "device")
+	archive := filepath.Join(dir, "archive")
+	writeEmptyActivity(t, filepath.Join(device, "Activity", "activity.fit"))
+
+	cmd := NewImportCommand()
+	err := cmd.ParseFlags([]string{"--archive", archive, "--timeout", "0"})
+	if err != nil {
+		t.Fatalf("parse flags: %s", err)
+	}
+
+	// neither postgres nor sqlite is configured
+	err = importDevice(cmd, []string{device})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	_, err = os.Stat(archive)
+	if !os.IsNotExist(err) {
+		t.Errorf("archive created: %v", err)
+	}
+}
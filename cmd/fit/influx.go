@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	fitcmd "github.com/subtlepseudonym/fit"
+
+	"github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	"github.com/spf13/pflag"
+	fit "github.com/subtlepseudonym/fit-go"
+)
+
+// influxSink writes records to influx as line protocol. Influx writes are
+// not transactional, so records are buffered until commit.
+type influxSink struct {
+	client    influxdb2.Client
+	writeAPI  api.WriteAPIBlocking
+	zones     fitcmd.Zones
+	elevation fitcmd.AccumulatorOption
+	lines     *bytes.Buffer
+}
+
+func newInfluxSink(flags *pflag.FlagSet) (*influxSink, error) {
+	influxHost, _ := flags.GetString("influx-host")
+	influxToken, _ := flags.GetString("influx-token")
+	influxOrg, _ := flags.GetString("influx-org")
+	influxBucket, _ := flags.GetString("influx-bucket")
+
+	// records are only tagged with heart rate zone if requested
+	zones, err := zoneTagFromFlags(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	elevation, err := elevationFromFlags(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	options := influxdb2.DefaultOptions()
+	options.SetPrecision(time.Second)
+
+	client := influxdb2.NewClientWithOptions(influxHost, influxToken, options)
+
+	return &influxSink{
+		client:    client,
+		writeAPI:  client.WriteAPIBlocking(influxOrg, influxBucket),
+		zones:     zones,
+		elevation: elevation,
+		lines:     new(bytes.Buffer),
+	}, nil
+}
+
+func (s *influxSink) Begin() error {
+	s.lines.Reset()
+	return nil
+}
+
+func (s *influxSink) WriteSummary(activity *fitcmd.Activity, source fitcmd.Source) error {
+	return nil
+}
+
+func (s *influxSink) WriteRecords(data *fit.File, tags map[string]string) error {
+	err := fitcmd.WriteLineProtocolWithZones(s.lines, data, tags, s.zones, s.elevation)
+	if err != nil {
+		return fmt.Errorf("write line protocol: %w", err)
+	}
+	return nil
+}
+
+func (s *influxSink) Commit() error {
+	defer s.lines.Reset()
+	err := s.writeAPI.WriteRecord(context.Background(), s.lines.String())
+	if err != nil {
+		return fmt.Errorf("write influx records: %w", err)
+	}
+	return nil
+}
+
+func (s *influxSink) Rollback() error {
+	s.lines.Reset()
+	return nil
+}
+
+func (s *influxSink) Close() error {
+	s.client.Close()
+	return nil
+}
@@ -28,11 +28,19 @@ func NewLineCommand() *cobra.Command {
 	}
 
 	cmd.Flags().String("device", DefaultDevice, "Telemetry device name")
+	cmd.Flags().Bool("zone-tag", false, "Tag records with heart rate zone")
+	addZoneFlags(cmd.Flags())
+	addElevationFlags(cmd.Flags())
 
 	return cmd
 }
 
 func line(cmd *cobra.Command, args []string) error {
+	elevation, err := elevationFromFlags(cmd.Flags())
+	if err != nil {
+		return err
+	}
+
 	for _, arg := range args {
 		file, err := os.Open(arg)
 		if err != nil {
@@ -69,7 +77,12 @@ func line(cmd *cobra.Command, args []string) error {
 			tags["ignore-file-checksum"] = "true"
 		}
 
-		err = fitcmd.WriteLineProtocol(output, data, tags)
+		zones, err := zoneTagFromFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		err = fitcmd.WriteLineProtocolWithZones(output, data, tags, zones, elevation)
 		if err != nil {
 			return fmt.Errorf("write line protocol: %w", err)
 		}
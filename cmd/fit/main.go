@@ -10,19 +10,30 @@ var Version string
 
 func main() {
 	root := &cobra.Command{
-		Use:          "fit",
-		Short:        "Interrogate and manipulate fit files",
-		Version:      Version,
-		SilenceUsage: true,
+		Use:               "fit",
+		Short:             "Interrogate and manipulate fit files",
+		Version:           Version,
+		SilenceUsage:      true,
+		PersistentPreRunE: loadConfig,
 	}
 
 	root.PersistentFlags().Bool("ignore-file-checksum", false, "Ignore file integrity checksum")
+	root.PersistentFlags().String("measurement-config", "", "YAML or JSON file registering additional record measurements")
+	root.PersistentFlags().String("type-config", "", "YAML or JSON file mapping sport names, sub-sports, and sports to activity types")
 
+	root.AddCommand(NewCSVCommand())
+	root.AddCommand(NewCurveCommand())
 	root.AddCommand(NewDumpCommand())
 	root.AddCommand(NewETLCommand())
+	root.AddCommand(NewGeoJSONCommand())
+	root.AddCommand(NewGPXCommand())
+	root.AddCommand(NewImportCommand())
 	root.AddCommand(NewInspectCommand())
+	root.AddCommand(NewKMLCommand())
 	root.AddCommand(NewLineCommand())
+	root.AddCommand(NewParquetCommand())
 	root.AddCommand(NewSummarizeCommand())
+	root.AddCommand(NewTCXCommand())
 	root.AddCommand(NewTypeCommand())
 
 	err := root.Execute()
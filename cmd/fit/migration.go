@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Migrations are named "<version>_<name>.<up|down>.sql" and are templated
+// with the configured table names. Up migrations must be idempotent so that
+// databases created before schema versioning can be upgraded in place.
+//
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+type migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+
+	// UpFunc and DownFunc are run after the migration query, within the
+	// same transaction, for changes that cannot be expressed in SQL
+	UpFunc   func(tx *sql.Tx) error
+	DownFunc func(tx *sql.Tx) error
+}
+
+// migrationFunc changes data that cannot be migrated in SQL alone
+type migrationFunc func(tx *sql.Tx, t tables) error
+
+// migrationFuncs are the up and down functions run by migrations, keyed by
+// migration version
+var migrationFuncs = map[int][2]migrationFunc{
+	13: {rehashActivities, restoreActivityHashes},
+}
+
+// loadMigrations returns all embedded migrations, ordered by version, with
+// table names substituted
+func loadMigrations(t tables) ([]*migration, error) {
+	entries, err := migrationFS.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		filename := entry.Name()
+		base := strings.TrimSuffix(filename, ".sql")
+		base, direction := strings.TrimSuffix(base, path.Ext(base)), strings.TrimPrefix(path.Ext(base), ".")
+
+		parts := strings.SplitN(base, "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid migration name: %s", filename)
+		}
+		version, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version: %s: %w", filename, err)
+		}
+
+		b, err := migrationFS.ReadFile(path.Join("migrations", filename))
+		if err != nil {
+			return nil, fmt.Errorf("read migration: %s: %w", filename, err)
+		}
+
+		tmpl, err := template.New(filename).Option("missingkey=error").Parse(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("parse migration: %s: %w", filename, err)
+		}
+
+		buf := new(bytes.Buffer)
+		err = tmpl.Execute(buf, t)
+		if err != nil {
+			return nil, fmt.Errorf("execute migration template: %s: %w", filename, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{Version: version, Name: parts[1]}
+			byVersion[version] = m
+		}
+
+		switch direction {
+		case "up":
+			m.Up = buf.String()
+		case "down":
+			m.Down = buf.String()
+		default:
+			return nil, fmt.Errorf("invalid migration direction: %s", filename)
+		}
+	}
+
+	migrations := make([]*migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d missing up or down", m.Version)
+		}
+		if funcs, ok := migrationFuncs[m.Version]; ok {
+			up, down := funcs[0], funcs[1]
+			m.UpFunc = func(tx *sql.Tx) error { return up(tx, t) }
+			m.DownFunc = func(tx *sql.Tx) error { return down(tx, t) }
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+const createSchemaVersionFormat = `
+CREATE TABLE IF NOT EXISTS %s
+(
+	version integer PRIMARY KEY,
+	name varchar(64) NOT NULL,
+	applied_at timestamptz NOT NULL DEFAULT NOW()
+);
+`
+
+const selectSchemaVersionsFormat = `
+SELECT version, applied_at FROM %s ORDER BY version;
+`
+
+// appliedMigrations creates the schema version table if necessary and
+// returns the time at which each applied migration version was applied
+func appliedMigrations(db *sql.DB, table string) (map[int]time.Time, error) {
+	_, err := db.Exec(fmt.Sprintf(createSchemaVersionFormat, table))
+	if err != nil {
+		return nil, fmt.Errorf("create schema version table: %w", err)
+	}
+
+	rows, err := db.Query(fmt.Sprintf(selectSchemaVersionsFormat, table))
+	if err != nil {
+		return nil, fmt.Errorf("select schema versions: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan schema version: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// schemaVersion returns the highest applied migration version, or zero if
+// no migrations have been applied
+func schemaVersion(applied map[int]time.Time) int {
+	var version int
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version
+}
+
+const insertSchemaVersionFormat = `
+INSERT INTO %s (version, name) VALUES ($1, $2);
+`
+
+const deleteSchemaVersionFormat = `
+DELETE FROM %s WHERE version = $1;
+`
+
+// migrateUp applies all unapplied migrations with a version less than or
+// equal to target, each in its own transaction
+func migrateUp(db *sql.DB, t tables, target int, applied func(*migration)) error {
+	migrations, err := loadMigrations(t)
+	if err != nil {
+		return err
+	}
+
+	versions, err := appliedMigrations(db, t.SchemaVersion)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version > target {
+			break
+		}
+		if _, ok := versions[m.Version]; ok {
+			continue
+		}
+
+		err = runMigration(db, m.Up, m.UpFunc, fmt.Sprintf(insertSchemaVersionFormat, t.SchemaVersion), m.Version, m.Name)
+		if err != nil {
+			return fmt.Errorf("migrate up: %d_%s: %w", m.Version, m.Name, err)
+		}
+		if applied != nil {
+			applied(m)
+		}
+	}
+
+	return nil
+}
+
+// migrateDown reverts all applied migrations with a version greater than
+// target, in descending order, each in its own transaction
+func migrateDown(db *sql.DB, t tables, target int, reverted func(*migration)) error {
+	migrations, err := loadMigrations(t)
+	if err != nil {
+		return err
+	}
+
+	versions, err := appliedMigrations(db, t.SchemaVersion)
+	if err != nil {
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version <= target {
+			break
+		}
+		if _, ok := versions[m.Version]; !ok {
+			continue
+		}
+
+		err = runMigration(db, m.Down, m.DownFunc, fmt.Sprintf(deleteSchemaVersionFormat, t.SchemaVersion), m.Version)
+		if err != nil {
+			return fmt.Errorf("migrate down: %d_%s: %w", m.Version, m.Name, err)
+		}
+		if reverted != nil {
+			reverted(m)
+		}
+	}
+
+	return nil
+}
+
+// runMigration executes the migration query and function, if any, and
+// records the change in schema version within a single transaction
+func runMigration(db *sql.DB, migrationQuery string, migrationFn func(*sql.Tx) error, versionQuery string, args ...interface{}) (ret error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin sql transaction: %w", err)
+	}
+	defer func() {
+		if ret != nil {
+			err := tx.Rollback()
+			if err != nil {
+				fmt.Println("ERR: failed to rollback transaction:", err)
+			}
+		}
+	}()
+
+	_, err = tx.Exec(migrationQuery)
+	if err != nil {
+		return fmt.Errorf("migration query: %w", err)
+	}
+
+	if migrationFn != nil {
+		err = migrationFn(tx)
+		if err != nil {
+			return fmt.Errorf("migration function: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(versionQuery, args...)
+	if err != nil {
+		return fmt.Errorf("schema version query: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit sql: %w", err)
+	}
+
+	return nil
+}
+
+const selectActivityHashFormat = `
+SELECT id, type, start_time, end_time FROM %s
+WHERE start_time IS NOT NULL AND end_time IS NOT NULL;
+`
+
+const updateActivityHashFormat = `
+UPDATE %s SET hash = $1 WHERE id = $2;
+`
+
+// rehashActivities recalculates activity hashes from start and end time
+func rehashActivities(tx *sql.Tx, t tables) error {
+	return updateActivityHashes(tx, t.Activity, func(activityType string, start, end time.Time) (int64, error) {
+		return activityHash(start, end)
+	})
+}
+
+// restoreActivityHashes recalculates activity hashes including type, as
+// they were before schema version 13
+func restoreActivityHashes(tx *sql.Tx, t tables) error {
+	return updateActivityHashes(tx, t.Activity, legacyActivityHash)
+}
+
+func updateActivityHashes(tx *sql.Tx, table string, hash func(activityType string, start, end time.Time) (int64, error)) error {
+	rows, err := tx.Query(fmt.Sprintf(selectActivityHashFormat, table))
+	if err != nil {
+		return fmt.Errorf("select activities: %w", err)
+	}
+	defer rows.Close()
+
+	// rows are read before updating, as queries can't be interleaved on a
+	// single connection
+	hashes := make(map[string]int64)
+	for rows.Next() {
+		var id string
+		var activityType sql.NullString
+		var start, end time.Time
+		err = rows.Scan(&id, &activityType, &start, &end)
+		if err != nil {
+			return fmt.Errorf("scan activity: %w", err)
+		}
+
+		hashes[id], err = hash(activityType.String, start, end)
+		if err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("select activities: %w", err)
+	}
+	rows.Close()
+
+	stmt, err := tx.Prepare(fmt.Sprintf(updateActivityHashFormat, table))
+	if err != nil {
+		return fmt.Errorf("prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	for id, h := range hashes {
+		_, err = stmt.Exec(h, id)
+		if err != nil {
+			return fmt.Errorf("update activity %s: %w", id, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS {{.Correlation}};
+DROP TABLE IF EXISTS {{.Measurement}};
+DROP TABLE IF EXISTS {{.Activity}};
+DROP FUNCTION IF EXISTS trigger_set_updated_at();
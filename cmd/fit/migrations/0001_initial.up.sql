@@ -0,0 +1,81 @@
+CREATE OR REPLACE FUNCTION trigger_set_updated_at()
+RETURNS TRIGGER AS $$
+BEGIN
+	NEW.updated_at = NOW();
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TABLE IF NOT EXISTS {{.Activity}}
+(
+	id varchar(64) PRIMARY KEY,
+	hash bigint UNIQUE NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	updated_at timestamptz NOT NULL DEFAULT NOW(),
+	type varchar(64),
+	start_time timestamptz,
+	end_time timestamptz,
+	tags jsonb
+);
+
+DROP TRIGGER IF EXISTS set_updated_at ON {{.Activity}};
+CREATE TRIGGER set_updated_at
+BEFORE UPDATE ON {{.Activity}}
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_updated_at();
+
+CREATE INDEX IF NOT EXISTS {{.Activity}}_start_time_idx ON {{.Activity}} (start_time);
+
+CREATE TABLE IF NOT EXISTS {{.Measurement}}
+(
+	id varchar(64) PRIMARY KEY,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	updated_at timestamptz NOT NULL DEFAULT NOW(),
+	activity_id varchar(64) NOT NULL REFERENCES {{.Activity}}(id)
+		ON DELETE RESTRICT
+		ON UPDATE RESTRICT,
+	name varchar(64) NOT NULL,
+	unit varchar(64),
+	maximum numeric(64, 32),
+	minimum numeric(64, 32),
+	median numeric(64, 32),
+	mean numeric(64, 32),
+	variance numeric(64, 32),
+	standard_deviation numeric(64, 32),
+	UNIQUE (activity_id, name)
+);
+
+DROP TRIGGER IF EXISTS set_updated_at ON {{.Measurement}};
+CREATE TRIGGER set_updated_at
+BEFORE UPDATE ON {{.Measurement}}
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_updated_at();
+
+CREATE TABLE IF NOT EXISTS {{.Correlation}}
+(
+	id varchar(64) PRIMARY KEY,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	updated_at timestamptz NOT NULL DEFAULT NOW(),
+	activity_id varchar(64) NOT NULL REFERENCES {{.Activity}}(id)
+		ON DELETE RESTRICT
+		ON UPDATE RESTRICT,
+	measurement_a varchar(64) NOT NULL,
+	measurement_b varchar(64) NOT NULL,
+	correlation numeric(32, 30),
+	FOREIGN KEY (activity_id, measurement_a)
+		REFERENCES {{.Measurement}}(activity_id, name),
+	FOREIGN KEY (activity_id, measurement_b)
+		REFERENCES {{.Measurement}}(activity_id, name)
+);
+
+DROP TRIGGER IF EXISTS set_updated_at ON {{.Correlation}};
+CREATE TRIGGER set_updated_at
+BEFORE UPDATE ON {{.Correlation}}
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_updated_at();
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Correlation}}_measurement_combination_idx ON {{.Correlation}}(
+	activity_id,
+	GREATEST(measurement_a, measurement_b),
+	LEAST(measurement_a, measurement_b)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE {{.Activity}} DROP CONSTRAINT IF EXISTS {{.Activity}}_import_id_fkey;
+ALTER TABLE {{.Activity}} DROP COLUMN IF EXISTS import_id;
+DROP TABLE IF EXISTS {{.Import}};
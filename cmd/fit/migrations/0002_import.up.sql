@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS {{.Import}}
+(
+	id varchar(64) PRIMARY KEY,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	updated_at timestamptz NOT NULL DEFAULT NOW(),
+	start_time timestamptz,
+	end_time timestamptz,
+	device varchar(64),
+	files varchar(64)[],
+	removed varchar(64)[],
+	errors varchar(128)[],
+	log text
+);
+
+DROP TRIGGER IF EXISTS set_updated_at ON {{.Import}};
+CREATE TRIGGER set_updated_at
+BEFORE UPDATE ON {{.Import}}
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_updated_at();
+
+CREATE INDEX IF NOT EXISTS {{.Import}}_start_time_idx ON {{.Import}} (start_time);
+
+ALTER TABLE {{.Activity}} ADD COLUMN IF NOT EXISTS import_id varchar(64);
+
+-- activities written before import tracking existed are attributed to a
+-- single placeholder import
+INSERT INTO {{.Import}} (id, log)
+SELECT 'legacy', 'activities imported before import tracking'
+WHERE EXISTS (SELECT 1 FROM {{.Activity}} WHERE import_id IS NULL)
+ON CONFLICT (id) DO NOTHING;
+
+UPDATE {{.Activity}} SET import_id = 'legacy' WHERE import_id IS NULL;
+
+ALTER TABLE {{.Activity}} ALTER COLUMN import_id SET NOT NULL;
+
+DO $$
+BEGIN
+	IF NOT EXISTS (
+		SELECT 1 FROM pg_constraint WHERE conname = '{{.Activity}}_import_id_fkey'
+	) THEN
+		ALTER TABLE {{.Activity}} ADD CONSTRAINT {{.Activity}}_import_id_fkey
+			FOREIGN KEY (import_id) REFERENCES {{.Import}}(id)
+			ON DELETE RESTRICT
+			ON UPDATE RESTRICT;
+	END IF;
+END;
+$$;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS {{.LapMeasurement}};
+DROP TABLE IF EXISTS {{.Lap}};
@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS {{.Lap}}
+(
+	id varchar(64) PRIMARY KEY,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	updated_at timestamptz NOT NULL DEFAULT NOW(),
+	activity_id varchar(64) NOT NULL REFERENCES {{.Activity}}(id)
+		ON DELETE RESTRICT
+		ON UPDATE RESTRICT,
+	lap_index integer NOT NULL,
+	start_time timestamptz,
+	end_time timestamptz,
+	UNIQUE (activity_id, lap_index)
+);
+
+DROP TRIGGER IF EXISTS set_updated_at ON {{.Lap}};
+CREATE TRIGGER set_updated_at
+BEFORE UPDATE ON {{.Lap}}
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_updated_at();
+
+CREATE INDEX IF NOT EXISTS {{.Lap}}_start_time_idx ON {{.Lap}} (start_time);
+
+CREATE TABLE IF NOT EXISTS {{.LapMeasurement}}
+(
+	id varchar(64) PRIMARY KEY,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	updated_at timestamptz NOT NULL DEFAULT NOW(),
+	lap_id varchar(64) NOT NULL REFERENCES {{.Lap}}(id)
+		ON DELETE RESTRICT
+		ON UPDATE RESTRICT,
+	name varchar(64) NOT NULL,
+	unit varchar(64),
+	maximum numeric(64, 32),
+	minimum numeric(64, 32),
+	median numeric(64, 32),
+	mean numeric(64, 32),
+	variance numeric(64, 32),
+	standard_deviation numeric(64, 32),
+	UNIQUE (lap_id, name)
+);
+
+DROP TRIGGER IF EXISTS set_updated_at ON {{.LapMeasurement}};
+CREATE TRIGGER set_updated_at
+BEFORE UPDATE ON {{.LapMeasurement}}
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_updated_at();
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS {{.ImportFile}};
+ALTER TABLE {{.Import}} DROP COLUMN IF EXISTS skipped;
@@ -0,0 +1,22 @@
+ALTER TABLE {{.Import}} ADD COLUMN IF NOT EXISTS skipped varchar(64)[];
+
+CREATE TABLE IF NOT EXISTS {{.ImportFile}}
+(
+	id varchar(64) PRIMARY KEY,
+	checksum varchar(64) UNIQUE NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	updated_at timestamptz NOT NULL DEFAULT NOW(),
+	import_id varchar(64) NOT NULL REFERENCES {{.Import}}(id)
+		ON DELETE RESTRICT
+		ON UPDATE RESTRICT,
+	activity_id varchar(64) REFERENCES {{.Activity}}(id)
+		ON DELETE RESTRICT
+		ON UPDATE RESTRICT,
+	filename varchar(64)
+);
+
+DROP TRIGGER IF EXISTS set_updated_at ON {{.ImportFile}};
+CREATE TRIGGER set_updated_at
+BEFORE UPDATE ON {{.ImportFile}}
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_updated_at();
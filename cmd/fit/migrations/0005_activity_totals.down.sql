@@ -0,0 +1 @@
+ALTER TABLE {{.Activity}} DROP COLUMN IF EXISTS totals;
@@ -0,0 +1 @@
+ALTER TABLE {{.Activity}} ADD COLUMN IF NOT EXISTS totals jsonb;
@@ -0,0 +1,7 @@
+ALTER TABLE {{.Activity}}
+	DROP COLUMN IF EXISTS average_power,
+	DROP COLUMN IF EXISTS normalized_power,
+	DROP COLUMN IF EXISTS variability_index,
+	DROP COLUMN IF EXISTS functional_threshold_power,
+	DROP COLUMN IF EXISTS intensity_factor,
+	DROP COLUMN IF EXISTS training_stress_score;
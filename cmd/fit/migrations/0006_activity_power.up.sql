@@ -0,0 +1,7 @@
+ALTER TABLE {{.Activity}}
+	ADD COLUMN IF NOT EXISTS average_power numeric(64, 32),
+	ADD COLUMN IF NOT EXISTS normalized_power numeric(64, 32),
+	ADD COLUMN IF NOT EXISTS variability_index numeric(64, 32),
+	ADD COLUMN IF NOT EXISTS functional_threshold_power numeric(64, 32),
+	ADD COLUMN IF NOT EXISTS intensity_factor numeric(64, 32),
+	ADD COLUMN IF NOT EXISTS training_stress_score numeric(64, 32);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS {{.Curve}}
+(
+	id varchar(64) PRIMARY KEY,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	updated_at timestamptz NOT NULL DEFAULT NOW(),
+	activity_id varchar(64) NOT NULL REFERENCES {{.Activity}}(id)
+		ON DELETE RESTRICT
+		ON UPDATE RESTRICT,
+	measurement varchar(64) NOT NULL,
+	unit varchar(64),
+	duration integer NOT NULL,
+	value numeric(64, 32),
+	UNIQUE (activity_id, measurement, duration)
+);
+
+DROP TRIGGER IF EXISTS set_updated_at ON {{.Curve}};
+CREATE TRIGGER set_updated_at
+BEFORE UPDATE ON {{.Curve}}
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_updated_at();
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS {{.HeartRateZone}}
+(
+	id varchar(64) PRIMARY KEY,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	updated_at timestamptz NOT NULL DEFAULT NOW(),
+	activity_id varchar(64) NOT NULL REFERENCES {{.Activity}}(id)
+		ON DELETE RESTRICT
+		ON UPDATE RESTRICT,
+	zone integer NOT NULL,
+	minimum numeric(64, 32),
+	maximum numeric(64, 32),
+	duration numeric(64, 32),
+	UNIQUE (activity_id, zone)
+);
+
+DROP TRIGGER IF EXISTS set_updated_at ON {{.HeartRateZone}};
+CREATE TRIGGER set_updated_at
+BEFORE UPDATE ON {{.HeartRateZone}}
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_updated_at();
@@ -0,0 +1,11 @@
+ALTER TABLE {{.LapMeasurement}}
+	DROP COLUMN IF EXISTS time_weighted_mean,
+	DROP COLUMN IF EXISTS time_weighted_median,
+	DROP COLUMN IF EXISTS time_weighted_variance,
+	DROP COLUMN IF EXISTS time_weighted_standard_deviation;
+
+ALTER TABLE {{.Measurement}}
+	DROP COLUMN IF EXISTS time_weighted_mean,
+	DROP COLUMN IF EXISTS time_weighted_median,
+	DROP COLUMN IF EXISTS time_weighted_variance,
+	DROP COLUMN IF EXISTS time_weighted_standard_deviation;
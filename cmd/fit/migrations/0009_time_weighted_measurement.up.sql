@@ -0,0 +1,11 @@
+ALTER TABLE {{.Measurement}}
+	ADD COLUMN IF NOT EXISTS time_weighted_mean numeric(64, 32),
+	ADD COLUMN IF NOT EXISTS time_weighted_median numeric(64, 32),
+	ADD COLUMN IF NOT EXISTS time_weighted_variance numeric(64, 32),
+	ADD COLUMN IF NOT EXISTS time_weighted_standard_deviation numeric(64, 32);
+
+ALTER TABLE {{.LapMeasurement}}
+	ADD COLUMN IF NOT EXISTS time_weighted_mean numeric(64, 32),
+	ADD COLUMN IF NOT EXISTS time_weighted_median numeric(64, 32),
+	ADD COLUMN IF NOT EXISTS time_weighted_variance numeric(64, 32),
+	ADD COLUMN IF NOT EXISTS time_weighted_standard_deviation numeric(64, 32);
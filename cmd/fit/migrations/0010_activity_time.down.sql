@@ -0,0 +1,5 @@
+ALTER TABLE {{.Activity}}
+	DROP COLUMN IF EXISTS elapsed_time,
+	DROP COLUMN IF EXISTS timer_time,
+	DROP COLUMN IF EXISTS moving_time,
+	DROP COLUMN IF EXISTS pauses;
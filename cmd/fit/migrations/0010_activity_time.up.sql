@@ -0,0 +1,5 @@
+ALTER TABLE {{.Activity}}
+	ADD COLUMN IF NOT EXISTS elapsed_time numeric(64, 32),
+	ADD COLUMN IF NOT EXISTS timer_time numeric(64, 32),
+	ADD COLUMN IF NOT EXISTS moving_time numeric(64, 32),
+	ADD COLUMN IF NOT EXISTS pauses jsonb;
@@ -0,0 +1,9 @@
+DELETE FROM {{.LapMeasurement}}
+WHERE lap_id IN (SELECT id FROM {{.Lap}} WHERE session);
+DELETE FROM {{.Lap}} WHERE session;
+
+DROP INDEX IF EXISTS {{.Lap}}_activity_id_session_lap_index_idx;
+ALTER TABLE {{.Lap}}
+	DROP COLUMN IF EXISTS session,
+	DROP COLUMN IF EXISTS sport,
+	ADD CONSTRAINT {{.Lap}}_activity_id_lap_index_key UNIQUE (activity_id, lap_index);
@@ -0,0 +1,9 @@
+ALTER TABLE {{.Lap}}
+	ADD COLUMN IF NOT EXISTS session boolean NOT NULL DEFAULT false,
+	ADD COLUMN IF NOT EXISTS sport varchar(64);
+
+-- laps and sessions are indexed independently
+ALTER TABLE {{.Lap}}
+	DROP CONSTRAINT IF EXISTS {{.Lap}}_activity_id_lap_index_key;
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Lap}}_activity_id_session_lap_index_idx
+	ON {{.Lap}} (activity_id, session, lap_index);
@@ -0,0 +1,2 @@
+ALTER TABLE {{.Activity}}
+	DROP COLUMN IF EXISTS days;
@@ -0,0 +1,2 @@
+ALTER TABLE {{.Activity}}
+	ADD COLUMN IF NOT EXISTS days jsonb;
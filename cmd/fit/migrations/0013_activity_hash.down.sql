@@ -0,0 +1 @@
+-- hashes including type are recalculated by restoreActivityHashes
@@ -0,0 +1,22 @@
+-- activities are hashed by start and end time, so activities that were
+-- imported again with a different type are removed, keeping the latest
+CREATE TEMPORARY TABLE duplicate_activity ON COMMIT DROP AS
+SELECT a.id FROM {{.Activity}} a
+WHERE EXISTS (
+	SELECT 1 FROM {{.Activity}} b
+	WHERE b.start_time = a.start_time
+		AND b.end_time = a.end_time
+		AND b.id > a.id
+);
+
+DELETE FROM {{.Correlation}} WHERE activity_id IN (SELECT id FROM duplicate_activity);
+DELETE FROM {{.Measurement}} WHERE activity_id IN (SELECT id FROM duplicate_activity);
+DELETE FROM {{.LapMeasurement}}
+WHERE lap_id IN (SELECT id FROM {{.Lap}} WHERE activity_id IN (SELECT id FROM duplicate_activity));
+DELETE FROM {{.Lap}} WHERE activity_id IN (SELECT id FROM duplicate_activity);
+DELETE FROM {{.Curve}} WHERE activity_id IN (SELECT id FROM duplicate_activity);
+DELETE FROM {{.HeartRateZone}} WHERE activity_id IN (SELECT id FROM duplicate_activity);
+UPDATE {{.ImportFile}} SET activity_id = NULL WHERE activity_id IN (SELECT id FROM duplicate_activity);
+DELETE FROM {{.Activity}} WHERE id IN (SELECT id FROM duplicate_activity);
+
+-- hashes are recalculated by rehashActivities
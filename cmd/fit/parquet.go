@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	fitcmd "github.com/subtlepseudonym/fit"
+
+	"github.com/spf13/cobra"
+	fit "github.com/subtlepseudonym/fit-go"
+)
+
+func NewParquetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "parquet",
+		Short: "Convert fit file records to a parquet dataset partitioned by type and month",
+		RunE:  parquet,
+	}
+
+	cmd.Flags().String("output", "fit.parquet", "Dataset directory")
+	addElevationFlags(cmd.Flags())
+
+	return cmd
+}
+
+func parquet(cmd *cobra.Command, args []string) error {
+	dir, _ := cmd.Flags().GetString("output")
+	elevation, err := elevationFromFlags(cmd.Flags())
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		b, err := os.ReadFile(arg)
+		if err != nil {
+			return fmt.Errorf("read: %w", err)
+		}
+
+		data, err := fit.Decode(bytes.NewReader(b))
+		if err != nil {
+			ignore, _ := cmd.Flags().GetBool("ignore-file-checksum")
+			_, ok := err.(fit.IntegrityError)
+			if !ignore || !ok {
+				return fmt.Errorf("%s: decode: %w", arg, err)
+			}
+		}
+
+		// archives contain monitoring and settings files alongside
+		// activities, so these are skipped rather than failing
+		if data.Type() != fit.FileTypeActivity {
+			fmt.Fprintf(os.Stderr, "skipped %s: file type %s\n", arg, data.Type())
+			continue
+		}
+
+		partition, err := fitcmd.ParquetPartition(data)
+		if errors.Is(err, fitcmd.ErrNoRecords) {
+			fmt.Fprintf(os.Stderr, "skipped %s: %s\n", arg, err)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: partition: %w", arg, err)
+		}
+
+		partitionDir := path.Join(dir, partition)
+		err = os.MkdirAll(partitionDir, 0755)
+		if err != nil {
+			return fmt.Errorf("mkdir: %w", err)
+		}
+
+		// the file checksum identifies the activity so that converting a
+		// file again replaces its previous output
+		sum := sha256.Sum256(b)
+		checksum := hex.EncodeToString(sum[:])
+
+		output, err := os.Create(path.Join(partitionDir, fmt.Sprintf("part-%s.parquet", checksum[:16])))
+		if err != nil {
+			return fmt.Errorf("open: %w", err)
+		}
+
+		err = fitcmd.WriteParquet(output, data, checksum, elevation)
+		if err != nil {
+			output.Close()
+			return fmt.Errorf("%s: write parquet: %w", arg, err)
+		}
+
+		err = output.Close()
+		if err != nil {
+			return fmt.Errorf("close: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"time"
 
 	fitcmd "github.com/subtlepseudonym/fit"
@@ -11,138 +12,101 @@ import (
 	"github.com/lib/pq"
 	"github.com/mitchellh/hashstructure"
 	"github.com/scru128/go-scru128"
+	"github.com/spf13/pflag"
 )
 
 // scruGenerator ensures that IDs generated by these queries
 // are time-sortable
 var scruGenerator = scru128.NewGenerator()
 
-const setupQueryFormat = `
-CREATE FUNCTION trigger_set_updated_at()
-RETURNS TRIGGER AS $$
-BEGIN
-	NEW.updated_at = NOW();
-	RETURN NEW;
-END;
-$$ LANGUAGE plpgsql;
-
-CREATE TABLE %s
-(
-	id varchar(64) PRIMARY KEY,
-	created_at timestamptz NOT NULL DEFAULT NOW(),
-	updated_at timestamptz NOT NULL DEFAULT NOW(),
-	start_time timestamptz,
-	end_time timestamptz,
-	device varchar(64),
-	files varchar(64)[],
-	removed varchar(64)[],
-	errors varchar(128)[],
-	log text
-);
-
-CREATE TRIGGER set_updated_at
-BEFORE UPDATE ON %s
-FOR EACH ROW
-EXECUTE PROCEDURE trigger_set_updated_at();
-
-CREATE INDEX ON %s (start_time);
-
-CREATE TABLE %s
-(
-	id varchar(64) PRIMARY KEY,
-	hash bigint UNIQUE NOT NULL,
-	created_at timestamptz NOT NULL DEFAULT NOW(),
-	updated_at timestamptz NOT NULL DEFAULT NOW(),
-	import_id varchar(64) NOT NULL REFERENCES %s(id)
-		ON DELETE RESTRICT
-		ON UPDATE RESTRICT,
-	type varchar(64),
-	start_time timestamptz,
-	end_time timestamptz,
-	tags jsonb
-);
-
-CREATE TRIGGER set_updated_at
-BEFORE UPDATE ON %s
-FOR EACH ROW
-EXECUTE PROCEDURE trigger_set_updated_at();
-
-CREATE INDEX ON %s (start_time);
+// query is a parameterized SQL statement and its arguments
+type query struct {
+	SQL  string
+	Args []interface{}
+}
 
-CREATE TABLE %s
-(
-	id varchar(64) PRIMARY KEY,
-	created_at timestamptz NOT NULL DEFAULT NOW(),
-	updated_at timestamptz NOT NULL DEFAULT NOW(),
-	activity_id varchar(64) NOT NULL REFERENCES %s(id)
-		ON DELETE RESTRICT
-		ON UPDATE RESTRICT,
-	name varchar(64) NOT NULL,
-	unit varchar(64),
-	maximum numeric(64, 32),
-	minimum numeric(64, 32),
-	median numeric(64, 32),
-	mean numeric(64, 32),
-	variance numeric(64, 32),
-	standard_deviation numeric(64, 32),
-	UNIQUE (activity_id, name)
-);
+// execQueries executes the queries in the transaction, preparing each
+// distinct statement once since measurement, correlation, and lap inserts
+// are repeated for every row
+func execQueries(tx *sql.Tx, queries []query) error {
+	stmts := make(map[string]*sql.Stmt)
+	defer func() {
+		for _, stmt := range stmts {
+			stmt.Close()
+		}
+	}()
+
+	for _, q := range queries {
+		stmt, ok := stmts[q.SQL]
+		if !ok {
+			var err error
+			stmt, err = tx.Prepare(q.SQL)
+			if err != nil {
+				return fmt.Errorf("prepare query: %w", err)
+			}
+			stmts[q.SQL] = stmt
+		}
 
-CREATE TRIGGER set_updated_at
-BEFORE UPDATE ON %s
-FOR EACH ROW
-EXECUTE PROCEDURE trigger_set_updated_at();
+		_, err := stmt.Exec(q.Args...)
+		if err != nil {
+			return fmt.Errorf("insert query: %w", err)
+		}
+	}
 
-CREATE TABLE %s
-(
-	id varchar(64) PRIMARY KEY,
-	created_at timestamptz NOT NULL DEFAULT NOW(),
-	updated_at timestamptz NOT NULL DEFAULT NOW(),
-	activity_id varchar(64) NOT NULL REFERENCES %s(id)
-		ON DELETE RESTRICT
-		ON UPDATE RESTRICT,
-	measurement_a varchar(64) NOT NULL,
-	measurement_b varchar(64) NOT NULL,
-	correlation numeric(32, 30),
-	FOREIGN KEY (activity_id, measurement_a)
-		REFERENCES %s(activity_id, name),
-	FOREIGN KEY (activity_id, measurement_b)
-		REFERENCES %s(activity_id, name)
-);
+	return nil
+}
 
-CREATE TRIGGER set_updated_at
-BEFORE UPDATE ON %s
-FOR EACH ROW
-EXECUTE PROCEDURE trigger_set_updated_at();
+// identifierRegexp matches unquoted postgres identifiers, which may be no
+// longer than 63 bytes
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+// tables holds the postgres table names used by the ETL commands
+type tables struct {
+	Import         string
+	Activity       string
+	Measurement    string
+	Correlation    string
+	Lap            string
+	LapMeasurement string
+	ImportFile     string
+	Curve          string
+	HeartRateZone  string
+	SchemaVersion  string
+}
 
-CREATE UNIQUE INDEX %s_measurement_combination_idx ON %s(
-	activity_id,
-	GREATEST(measurement_a, measurement_b),
-	LEAST(measurement_a, measurement_b)
-);
-`
+// tablesFromFlags reads table names from flags and validates that each is
+// safe to interpolate into a query as an identifier
+func tablesFromFlags(flags *pflag.FlagSet) (tables, error) {
+	var t tables
+	t.Import, _ = flags.GetString("postgres-import-table")
+	t.Activity, _ = flags.GetString("postgres-activity-table")
+	t.Measurement, _ = flags.GetString("postgres-measurement-table")
+	t.Correlation, _ = flags.GetString("postgres-correlation-table")
+	t.Lap, _ = flags.GetString("postgres-lap-table")
+	t.LapMeasurement, _ = flags.GetString("postgres-lap-measurement-table")
+	t.ImportFile, _ = flags.GetString("postgres-import-file-table")
+	t.Curve, _ = flags.GetString("postgres-curve-table")
+	t.HeartRateZone, _ = flags.GetString("postgres-heart-rate-zone-table")
+	t.SchemaVersion, _ = flags.GetString("postgres-schema-version-table")
+
+	for _, table := range []string{
+		t.Import,
+		t.Activity,
+		t.Measurement,
+		t.Correlation,
+		t.Lap,
+		t.LapMeasurement,
+		t.ImportFile,
+		t.Curve,
+		t.HeartRateZone,
+		t.SchemaVersion,
+	} {
+		if !identifierRegexp.MatchString(table) {
+			return t, fmt.Errorf("invalid table name: %q", table)
+		}
+	}
 
-func buildSetupQuery(importTable, activityTable, measurementTable, correlationTable string) string {
-	return fmt.Sprintf(
-		setupQueryFormat,
-		importTable,
-		importTable,
-		importTable,
-		activityTable,
-		importTable,
-		activityTable,
-		activityTable,
-		measurementTable,
-		activityTable,
-		measurementTable,
-		correlationTable,
-		activityTable,
-		measurementTable,
-		measurementTable,
-		correlationTable,
-		correlationTable,
-		correlationTable,
-	)
+	return t, nil
 }
 
 const insertImportFormat = `
@@ -159,24 +123,105 @@ INSERT INTO %s
 func insertImport(db *sql.DB, table string, start time.Time, device string) (string, error) {
 	query := fmt.Sprintf(insertImportFormat, table)
 	importID, err := scruGenerator.Generate()
+	if err != nil {
+		return "", fmt.Errorf("generate import ID: %w", err)
+	}
+
 	_, err = db.Exec(query, importID.String(), start.Format(time.RFC3339), device)
 	return importID.String(), err
 }
 
+// importRecord contains the results of an import run
+type importRecord struct {
+	ID      string
+	End     time.Time
+	Files   []string
+	Skipped []string
+	Removed []string
+	Errors  []string
+	Log     string
+}
+
 const updateImportFormat = `
 UPDATE %s SET
 	end_time = $1,
 	files = $2,
-	errors = $3
-WHERE id = $4;
+	skipped = $3,
+	removed = $4,
+	errors = $5,
+	log = $6
+WHERE id = $7;
 `
 
-func updateImport(db *sql.DB, table, importID string, end time.Time, files, errors []string) error {
+func updateImport(db *sql.DB, table string, record importRecord) error {
 	query := fmt.Sprintf(updateImportFormat, table)
-	_, err := db.Exec(query, end.Format(time.RFC3339), pq.Array(files), pq.Array(errors), importID)
+	_, err := db.Exec(
+		query,
+		record.End.Format(time.RFC3339),
+		pq.Array(record.Files),
+		pq.Array(record.Skipped),
+		pq.Array(record.Removed),
+		pq.Array(record.Errors),
+		record.Log,
+		record.ID,
+	)
 	return err
 }
 
+const selectImportFileFormat = `
+SELECT EXISTS (SELECT 1 FROM %s WHERE checksum = $1);
+`
+
+// fileImported returns whether a file with the given checksum has been
+// successfully imported
+func fileImported(db *sql.DB, table, checksum string) (bool, error) {
+	var exists bool
+	err := db.QueryRow(fmt.Sprintf(selectImportFileFormat, table), checksum).Scan(&exists)
+	return exists, err
+}
+
+const insertImportFileFormat = `
+INSERT INTO %s
+(
+	id,
+	checksum,
+	import_id,
+	activity_id,
+	filename
+) VALUES (
+	$1, $2, $3, $4, $5
+) ON CONFLICT (checksum)
+DO UPDATE SET
+	import_id = EXCLUDED.import_id,
+	activity_id = EXCLUDED.activity_id,
+	filename = EXCLUDED.filename;
+`
+
+// buildImportFileQuery records a file as imported. Files without records
+// have no activity, which is stored as null.
+func buildImportFileQuery(table, checksum, importID, activityID, filename string) (query, error) {
+	id, err := scruGenerator.Generate()
+	if err != nil {
+		return query{}, fmt.Errorf("generate scru ID: %w", err)
+	}
+
+	var activity interface{}
+	if activityID != "" {
+		activity = activityID
+	}
+
+	return query{
+		SQL: fmt.Sprintf(insertImportFileFormat, table),
+		Args: []interface{}{
+			id.String(),
+			checksum,
+			importID,
+			activity,
+			filename,
+		},
+	}, nil
+}
+
 const insertActivityFormat = `
 INSERT INTO %s
 (
@@ -186,46 +231,171 @@ INSERT INTO %s
 	type,
 	start_time,
 	end_time,
-	tags
+	tags,
+	totals,
+	average_power,
+	normalized_power,
+	variability_index,
+	functional_threshold_power,
+	intensity_factor,
+	training_stress_score,
+	elapsed_time,
+	timer_time,
+	moving_time,
+	pauses,
+	days
 ) VALUES (
-	'%s', %d, '%s', '%s', '%s', '%s', '%s'
+	$1, $2, $3, $4, $5, $6, $7, $8,
+	$9, $10, $11, $12, $13, $14,
+	$15, $16, $17, $18, $19
 ) ON CONFLICT (hash)
 DO UPDATE SET
 	import_id = EXCLUDED.import_id,
 	type = EXCLUDED.type,
 	start_time = EXCLUDED.start_time,
 	end_time = EXCLUDED.end_time,
-	tags = EXCLUDED.tags
+	tags = EXCLUDED.tags,
+	totals = EXCLUDED.totals,
+	average_power = EXCLUDED.average_power,
+	normalized_power = EXCLUDED.normalized_power,
+	variability_index = EXCLUDED.variability_index,
+	functional_threshold_power = EXCLUDED.functional_threshold_power,
+	intensity_factor = EXCLUDED.intensity_factor,
+	training_stress_score = EXCLUDED.training_stress_score,
+	elapsed_time = EXCLUDED.elapsed_time,
+	timer_time = EXCLUDED.timer_time,
+	moving_time = EXCLUDED.moving_time,
+	pauses = EXCLUDED.pauses,
+	days = EXCLUDED.days
 RETURNING id;
 `
 
-func buildActivityQuery(table string, activity *fitcmd.Activity, importID string) (string, error) {
+// activityHash identifies an activity by its start and end time, which,
+// unlike its type or summary, do not change when the file is re-imported
+// with a different configuration
+func activityHash(start, end time.Time) (int64, error) {
+	hash, err := hashstructure.Hash(struct {
+		StartTime time.Time
+		EndTime   time.Time
+	}{start.UTC(), end.UTC()}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("hash activity: %w", err)
+	}
+	return int64(hash), nil
+}
+
+// legacyActivityHash is the activity hash used before schema version 13,
+// which included the activity type
+func legacyActivityHash(activityType string, start, end time.Time) (int64, error) {
+	type Activity struct {
+		Type      string
+		StartTime time.Time
+		EndTime   time.Time
+	}
+
+	hash, err := hashstructure.Hash(Activity{activityType, start.UTC(), end.UTC()}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("hash activity: %w", err)
+	}
+	return int64(hash), nil
+}
+
+func buildActivityQuery(table string, activity *fitcmd.Activity, importID string) (query, error) {
 	activityID, err := scruGenerator.Generate()
 	if err != nil {
-		return "", fmt.Errorf("generate activity ID: %w", err)
+		return query{}, fmt.Errorf("generate activity ID: %w", err)
 	}
 
-	hash, err := hashstructure.Hash(activity, nil)
+	hash, err := activityHash(activity.StartTime, activity.EndTime)
 	if err != nil {
-		return "", fmt.Errorf("hash activity: %w", err)
+		return query{}, err
 	}
 
 	tags, err := json.Marshal(activity.Tags)
 	if err != nil {
-		return "", fmt.Errorf("marshal json tags: %w", err)
+		return query{}, fmt.Errorf("marshal json tags: %w", err)
+	}
+
+	var totals interface{}
+	if activity.Totals != nil {
+		b, err := json.Marshal(activity.Totals)
+		if err != nil {
+			return query{}, fmt.Errorf("marshal json totals: %w", err)
+		}
+		totals = string(b)
+	}
+
+	var pauses interface{}
+	if activity.Pauses != nil {
+		b, err := json.Marshal(activity.Pauses)
+		if err != nil {
+			return query{}, fmt.Errorf("marshal json pauses: %w", err)
+		}
+		pauses = string(b)
 	}
 
-	return fmt.Sprintf(
-		insertActivityFormat,
-		table,
-		activityID,
-		int64(hash),
+	var days interface{}
+	if activity.Days != nil {
+		b, err := json.Marshal(activity.Days)
+		if err != nil {
+			return query{}, fmt.Errorf("marshal json days: %w", err)
+		}
+		days = string(b)
+	}
+
+	// power columns are null for activities without power data
+	power := make([]interface{}, 6)
+	if p := activity.Power; p != nil {
+		power = []interface{}{
+			p.Average,
+			p.NormalizedPower,
+			p.VariabilityIndex,
+			nullIfZero(p.FunctionalThresholdPower),
+			nullIfZero(p.IntensityFactor),
+			nullIfZero(p.TrainingStressScore),
+		}
+	}
+
+	args := []interface{}{
+		activityID.String(),
+		hash,
 		importID,
 		activity.Type,
 		activity.StartTime.Format(time.RFC3339),
 		activity.EndTime.Format(time.RFC3339),
-		tags,
-	), nil
+		string(tags),
+		totals,
+	}
+	args = append(args, power...)
+	args = append(args,
+		nullIfZero(activity.ElapsedTime),
+		nullIfZero(activity.TimerTime),
+		nullIfZero(activity.MovingTime),
+		pauses,
+		days,
+	)
+
+	return query{
+		SQL:  fmt.Sprintf(insertActivityFormat, table),
+		Args: args,
+	}, nil
+}
+
+// timeWeighted returns nil for time-weighted aggregates that were not
+// calculated so they are stored as null
+func timeWeighted(m *fitcmd.Measurement, v float64) interface{} {
+	if !m.TimeWeighted {
+		return nil
+	}
+	return v
+}
+
+// nullIfZero returns nil for unset values so they are stored as null
+func nullIfZero(v float64) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
 }
 
 const insertMeasurementFormat = `
@@ -240,10 +410,15 @@ INSERT INTO %s
 	median,
 	mean,
 	variance,
-	standard_deviation
+	standard_deviation,
+	time_weighted_mean,
+	time_weighted_median,
+	time_weighted_variance,
+	time_weighted_standard_deviation
 ) VALUES (
-	'%s', '%s', '%s', '%s',
-	%f, %f, %f, %f, %f, %f
+	$1, $2, $3, $4,
+	$5, $6, $7, $8, $9, $10,
+	$11, $12, $13, $14
 ) ON CONFLICT (activity_id, name)
 DO UPDATE SET
 	unit = EXCLUDED.unit,
@@ -252,7 +427,11 @@ DO UPDATE SET
 	median = EXCLUDED.median,
 	mean = EXCLUDED.mean,
 	variance = EXCLUDED.variance,
-	standard_deviation = EXCLUDED.standard_deviation;
+	standard_deviation = EXCLUDED.standard_deviation,
+	time_weighted_mean = EXCLUDED.time_weighted_mean,
+	time_weighted_median = EXCLUDED.time_weighted_median,
+	time_weighted_variance = EXCLUDED.time_weighted_variance,
+	time_weighted_standard_deviation = EXCLUDED.time_weighted_standard_deviation;
 `
 
 const insertCorrelationFormat = `
@@ -264,7 +443,7 @@ INSERT INTO %s
 	measurement_b,
 	correlation
 ) VALUES (
-	'%s', '%s', '%s', '%s', %f
+	$1, $2, $3, $4, $5
 ) ON CONFLICT (
 	activity_id,
 	GREATEST(measurement_a, measurement_b),
@@ -274,46 +453,274 @@ DO UPDATE SET
 	correlation = EXCLUDED.correlation;
 `
 
-func buildQueries(measurementTable, correlationTable, activityID string, activity *fitcmd.Activity) ([]string, error) {
-	queries := make([]string, 0, len(activity.Measurements)+len(activity.Correlations))
+// laps and lap measurements are replaced rather than updated so that laps
+// removed from a re-imported activity are not kept
+const deleteLapMeasurementFormat = `
+DELETE FROM %s
+WHERE lap_id IN (SELECT id FROM %s WHERE activity_id = $1);
+`
+
+const deleteLapFormat = `
+DELETE FROM %s WHERE activity_id = $1;
+`
+
+// curves are replaced so that points removed from a re-imported activity
+// are not kept
+const deleteCurveFormat = `
+DELETE FROM %s WHERE activity_id = $1;
+`
+
+// heart rate zones are replaced so that zones removed from a re-imported
+// activity, such as after changing '--heart-rate-zones', are not kept
+const deleteHeartRateZoneFormat = `
+DELETE FROM %s WHERE activity_id = $1;
+`
+
+const insertLapFormat = `
+INSERT INTO %s
+(
+	id,
+	activity_id,
+	session,
+	lap_index,
+	sport,
+	start_time,
+	end_time
+) VALUES (
+	$1, $2, $3, $4, $5, $6, $7
+);
+`
 
+// lap ID is selected so that lap measurements needn't track the generated
+// lap ID
+const insertLapMeasurementFormat = `
+INSERT INTO %s
+(
+	id,
+	lap_id,
+	name,
+	unit,
+	maximum,
+	minimum,
+	median,
+	mean,
+	variance,
+	standard_deviation,
+	time_weighted_mean,
+	time_weighted_median,
+	time_weighted_variance,
+	time_weighted_standard_deviation
+) VALUES (
+	$1,
+	(SELECT id FROM %s WHERE activity_id = $2 AND session = $3 AND lap_index = $4),
+	$5, $6,
+	$7, $8, $9, $10, $11, $12,
+	$13, $14, $15, $16
+);
+`
+
+const insertCurveFormat = `
+INSERT INTO %s
+(
+	id,
+	activity_id,
+	measurement,
+	unit,
+	duration,
+	value
+) VALUES (
+	$1, $2, $3, $4, $5, $6
+);
+`
+
+const insertHeartRateZoneFormat = `
+INSERT INTO %s
+(
+	id,
+	activity_id,
+	zone,
+	minimum,
+	maximum,
+	duration
+) VALUES (
+	$1, $2, $3, $4, $5, $6
+);
+`
+
+func buildQueries(t tables, activityID string, activity *fitcmd.Activity) ([]query, error) {
+	queries := make([]query, 0, len(activity.Measurements)+len(activity.Correlations)+len(activity.Laps)+len(activity.Sessions)+2)
+
+	measurementQuery := fmt.Sprintf(insertMeasurementFormat, t.Measurement)
 	for _, m := range activity.Measurements {
 		id, err := scruGenerator.Generate()
 		if err != nil {
 			return nil, fmt.Errorf("generate scru ID: %w", err)
 		}
 
-		queries = append(queries, fmt.Sprintf(
-			insertMeasurementFormat,
-			measurementTable,
-			id,
-			activityID,
-			m.Name,
-			m.Unit,
-			m.Maximum,
-			m.Minimum,
-			m.Median,
-			m.Mean,
-			m.Variance,
-			m.StandardDeviation,
-		))
+		queries = append(queries, query{
+			SQL: measurementQuery,
+			Args: []interface{}{
+				id.String(),
+				activityID,
+				m.Name,
+				m.Unit,
+				m.Maximum,
+				m.Minimum,
+				m.Median,
+				m.Mean,
+				m.Variance,
+				m.StandardDeviation,
+				timeWeighted(m, m.TimeWeightedMean),
+				timeWeighted(m, m.TimeWeightedMedian),
+				timeWeighted(m, m.TimeWeightedVariance),
+				timeWeighted(m, m.TimeWeightedStandardDeviation),
+			},
+		})
 	}
 
+	correlationQuery := fmt.Sprintf(insertCorrelationFormat, t.Correlation)
 	for _, c := range activity.Correlations {
 		id, err := scruGenerator.Generate()
 		if err != nil {
 			return nil, fmt.Errorf("generate scru ID: %w", err)
 		}
 
-		queries = append(queries, fmt.Sprintf(
-			insertCorrelationFormat,
-			correlationTable,
-			id,
-			activityID,
-			c.MeasurementA,
-			c.MeasurementB,
-			c.Correlation,
-		))
+		queries = append(queries, query{
+			SQL: correlationQuery,
+			Args: []interface{}{
+				id.String(),
+				activityID,
+				c.MeasurementA,
+				c.MeasurementB,
+				c.Correlation,
+			},
+		})
+	}
+
+	queries = append(queries,
+		query{
+			SQL:  fmt.Sprintf(deleteLapMeasurementFormat, t.LapMeasurement, t.Lap),
+			Args: []interface{}{activityID},
+		},
+		query{
+			SQL:  fmt.Sprintf(deleteLapFormat, t.Lap),
+			Args: []interface{}{activityID},
+		},
+	)
+
+	lapQuery := fmt.Sprintf(insertLapFormat, t.Lap)
+	lapMeasurementQuery := fmt.Sprintf(insertLapMeasurementFormat, t.LapMeasurement, t.Lap)
+	for _, session := range []bool{false, true} {
+		laps := activity.Laps
+		if session {
+			laps = activity.Sessions
+		}
+
+		for _, lap := range laps {
+			id, err := scruGenerator.Generate()
+			if err != nil {
+				return nil, fmt.Errorf("generate scru ID: %w", err)
+			}
+
+			var sport interface{}
+			if lap.Sport != "" {
+				sport = lap.Sport
+			}
+
+			queries = append(queries, query{
+				SQL: lapQuery,
+				Args: []interface{}{
+					id.String(),
+					activityID,
+					session,
+					lap.Index,
+					sport,
+					lap.StartTime.Format(time.RFC3339),
+					lap.EndTime.Format(time.RFC3339),
+				},
+			})
+
+			for _, m := range lap.Measurements {
+				id, err := scruGenerator.Generate()
+				if err != nil {
+					return nil, fmt.Errorf("generate scru ID: %w", err)
+				}
+
+				queries = append(queries, query{
+					SQL: lapMeasurementQuery,
+					Args: []interface{}{
+						id.String(),
+						activityID,
+						session,
+						lap.Index,
+						m.Name,
+						m.Unit,
+						m.Maximum,
+						m.Minimum,
+						m.Median,
+						m.Mean,
+						m.Variance,
+						m.StandardDeviation,
+						timeWeighted(m, m.TimeWeightedMean),
+						timeWeighted(m, m.TimeWeightedMedian),
+						timeWeighted(m, m.TimeWeightedVariance),
+						timeWeighted(m, m.TimeWeightedStandardDeviation),
+					},
+				})
+			}
+		}
+	}
+
+	queries = append(queries, query{
+		SQL:  fmt.Sprintf(deleteCurveFormat, t.Curve),
+		Args: []interface{}{activityID},
+	})
+
+	curveQuery := fmt.Sprintf(insertCurveFormat, t.Curve)
+	for _, curve := range activity.Curves {
+		for _, point := range curve.Points {
+			id, err := scruGenerator.Generate()
+			if err != nil {
+				return nil, fmt.Errorf("generate scru ID: %w", err)
+			}
+
+			queries = append(queries, query{
+				SQL: curveQuery,
+				Args: []interface{}{
+					id.String(),
+					activityID,
+					curve.Measurement,
+					curve.Unit,
+					point.Duration,
+					point.Value,
+				},
+			})
+		}
+	}
+
+	queries = append(queries, query{
+		SQL:  fmt.Sprintf(deleteHeartRateZoneFormat, t.HeartRateZone),
+		Args: []interface{}{activityID},
+	})
+
+	zoneQuery := fmt.Sprintf(insertHeartRateZoneFormat, t.HeartRateZone)
+	for _, zone := range activity.HeartRateZones {
+		id, err := scruGenerator.Generate()
+		if err != nil {
+			return nil, fmt.Errorf("generate scru ID: %w", err)
+		}
+
+		queries = append(queries, query{
+			SQL: zoneQuery,
+			Args: []interface{}{
+				id.String(),
+				activityID,
+				zone.Zone,
+				zone.Minimum,
+				nullIfZero(zone.Maximum),
+				zone.Duration,
+			},
+		})
 	}
 
 	return queries, nil
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	fitcmd "github.com/subtlepseudonym/fit"
+)
+
+// hostileValues are tag, type, and measurement values that break or inject
+// SQL if interpolated into a query
+var hostileValues = []string{
+	`O'Brien's watch`,
+	`'); DROP TABLE activity; --`,
+	`"quoted"; SELECT 1;`,
+	`back\slash\'`,
+	`$1 $$ dollar`,
+	"new\nline",
+}
+
+func TestTablesFromFlagsRejectsHostileNames(t *testing.T) {
+	for _, name := range append(hostileValues, "", "1activity", "activity;") {
+		flags := NewETLCommand().PersistentFlags()
+		err := flags.Set("postgres-activity-table", name)
+		if err != nil {
+			t.Fatalf("set flag: %s", err)
+		}
+
+		_, err = tablesFromFlags(flags)
+		if err == nil {
+			t.Errorf("table name %q: expected error", name)
+		}
+	}
+}
+
+func TestBuildActivityQueryHostileValues(t *testing.T) {
+	for _, value := range hostileValues {
+		activity := &fitcmd.Activity{
+			Type:      value,
+			StartTime: time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC),
+			EndTime:   time.Date(2023, 8, 1, 13, 0, 0, 0, time.UTC),
+			Tags: map[string]string{
+				"device": value,
+				value:    "key",
+			},
+		}
+
+		q, err := buildActivityQuery("activity", activity, "import")
+		if err != nil {
+			t.Fatalf("%q: build activity query: %s", value, err)
+		}
+
+		if strings.Contains(q.SQL, value) {
+			t.Errorf("%q: value interpolated into SQL", value)
+		}
+		if got := strings.Count(q.SQL, "$"); got != len(q.Args) {
+			t.Errorf("%q: %d placeholders, %d args", value, got, len(q.Args))
+		}
+		if q.Args[3] != value {
+			t.Errorf("%q: type arg: got %q", value, q.Args[3])
+		}
+
+		var tags map[string]string
+		err = json.Unmarshal([]byte(q.Args[6].(string)), &tags)
+		if err != nil {
+			t.Fatalf("%q: unmarshal tags: %s", value, err)
+		}
+		if !reflect.DeepEqual(tags, activity.Tags) {
+			t.Errorf("%q: tags arg: got %v, expected %v", value, tags, activity.Tags)
+		}
+	}
+}
+
+func TestBuildQueriesHostileValues(t *testing.T) {
+	tbl := tables{
+		Measurement:    "measurement",
+		Correlation:    "correlation",
+		Lap:            "lap",
+		LapMeasurement: "lap_measurement",
+		Curve:          "curve",
+		HeartRateZone:  "heart_rate_zone",
+	}
+
+	for _, value := range hostileValues {
+		measurement := &fitcmd.Measurement{Name: value, Unit: value}
+		activity := &fitcmd.Activity{
+			Measurements: []*fitcmd.Measurement{measurement},
+			Correlations: []*fitcmd.Correlation{
+				{MeasurementA: value, MeasurementB: "heart_rate"},
+			},
+			Laps: []*fitcmd.LapSummary{
+				{Index: 0, Sport: value, Measurements: []*fitcmd.Measurement{measurement}},
+			},
+		}
+
+		queries, err := buildQueries(tbl, value, activity)
+		if err != nil {
+			t.Fatalf("%q: build queries: %s", value, err)
+		}
+
+		var found int
+		for _, q := range queries {
+			if strings.Contains(q.SQL, value) {
+				t.Errorf("%q: value interpolated into SQL: %s", value, q.SQL)
+			}
+			for _, arg := range q.Args {
+				if arg == value {
+					found++
+				}
+			}
+		}
+
+		// activity ID in every query, measurement name and unit, lap sport,
+		// lap measurement name and unit, and correlation measurement
+		if expected := len(queries) + 6; found != expected {
+			t.Errorf("%q: found value in %d args, expected %d", value, found, expected)
+		}
+	}
+}
+
+func TestDeviceTagRoundTrip(t *testing.T) {
+	for _, device := range hostileValues {
+		cmd := NewETLCommand()
+		err := cmd.ParseFlags([]string{
+			"--sqlite", filepath.Join(t.TempDir(), "fit.db"),
+			"--device", device,
+		})
+		if err != nil {
+			t.Fatalf("parse flags: %s", err)
+		}
+
+		p, err := newPipeline(cmd)
+		if err != nil {
+			t.Fatalf("new pipeline: %s", err)
+		}
+		defer p.Close()
+
+		importID, err := p.imports.InsertImport(time.Now(), device)
+		if err != nil {
+			t.Fatalf("%q: insert import: %s", device, err)
+		}
+
+		activity := &fitcmd.Activity{
+			Type:      "run",
+			StartTime: time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC),
+			EndTime:   time.Date(2023, 8, 1, 13, 0, 0, 0, time.UTC),
+			Tags:      p.tags,
+		}
+		err = p.imports.Begin()
+		if err != nil {
+			t.Fatalf("%q: begin: %s", device, err)
+		}
+		err = p.imports.WriteSummary(activity, fitcmd.Source{ImportID: importID, Checksum: "checksum", Filename: device})
+		if err != nil {
+			t.Fatalf("%q: write summary: %s", device, err)
+		}
+		err = p.imports.Commit()
+		if err != nil {
+			t.Fatalf("%q: commit: %s", device, err)
+		}
+
+		db := p.imports.(*sqliteStorage).db
+		var importDevice, tagsJSON string
+		err = db.QueryRow(`SELECT device FROM import WHERE id = ?;`, importID).Scan(&importDevice)
+		if err != nil {
+			t.Fatalf("%q: select import: %s", device, err)
+		}
+		err = db.QueryRow(`SELECT tags FROM activity WHERE import_id = ?;`, importID).Scan(&tagsJSON)
+		if err != nil {
+			t.Fatalf("%q: select activity: %s", device, err)
+		}
+
+		var tags map[string]string
+		err = json.Unmarshal([]byte(tagsJSON), &tags)
+		if err != nil {
+			t.Fatalf("%q: unmarshal tags: %s", device, err)
+		}
+		if importDevice != device || tags["device"] != device {
+			t.Errorf("device %q: got import device %q, tag %q", device, importDevice, tags["device"])
+		}
+	}
+}
+
+func TestBuildQueriesTimeWeighted(t *testing.T) {
+	tbl := tables{Measurement: "measurement"}
+	tests := []struct {
+		measurement *fitcmd.Measurement
+		expected    []interface{}
+	}{
+		{
+			measurement: &fitcmd.Measurement{Name: "power", TimeWeighted: true},
+			expected:    []interface{}{0.0, 0.0, 0.0, 0.0},
+		},
+		{
+			measurement: &fitcmd.Measurement{Name: "power"},
+			expected:    []interface{}{nil, nil, nil, nil},
+		},
+	}
+
+	for _, test := range tests {
+		activity := &fitcmd.Activity{
+			Measurements: []*fitcmd.Measurement{test.measurement},
+		}
+
+		queries, err := buildQueries(tbl, "activity", activity)
+		if err != nil {
+			t.Fatalf("build queries: %s", err)
+		}
+
+		var args []interface{}
+		for _, q := range queries {
+			if strings.Contains(q.SQL, "INSERT INTO measurement") {
+				args = q.Args[len(q.Args)-4:]
+			}
+		}
+		if !reflect.DeepEqual(args, test.expected) {
+			t.Errorf("time weighted %t: got %v, expected %v", test.measurement.TimeWeighted, args, test.expected)
+		}
+	}
+}
+
+func TestActivityHash(t *testing.T) {
+	start := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	var hashes []interface{}
+	for _, activityType := range []string{"unknown", "hike"} {
+		activity := &fitcmd.Activity{Type: activityType, StartTime: start, EndTime: end}
+		q, err := buildActivityQuery("activity", activity, "import")
+		if err != nil {
+			t.Fatalf("build activity query: %s", err)
+		}
+		hashes = append(hashes, q.Args[1])
+	}
+	if hashes[0] != hashes[1] {
+		t.Errorf("hash changed with type: %v", hashes)
+	}
+
+	// hashes read back from the database may be in another time zone
+	local, err := activityHash(start.In(time.FixedZone("", -5*60*60)), end)
+	if err != nil {
+		t.Fatalf("hash: %s", err)
+	}
+	if local != hashes[0] {
+		t.Errorf("hash changed with time zone: got %d, expected %d", local, hashes[0])
+	}
+
+	// hash of the activity before type was ignored
+	legacy, err := legacyActivityHash("run", start, end)
+	if err != nil {
+		t.Fatalf("legacy hash: %s", err)
+	}
+	if expected := int64(6035470058038629476); legacy != expected {
+		t.Errorf("legacy hash: got %d, expected %d", legacy, expected)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	fitcmd "github.com/subtlepseudonym/fit"
+
+	"github.com/spf13/cobra"
+	fit "github.com/subtlepseudonym/fit-go"
+)
+
+type routeWriter func(io.Writer, *fit.File, *fitcmd.Activity, float64) error
+
+func NewGeoJSONCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "geojson",
+		Short: "Convert fit file route to GeoJSON",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return route(cmd, args, "geojson", fitcmd.WriteGeoJSON)
+		},
+	}
+
+	cmd.Flags().Float64("simplify", 0, "Douglas-Peucker simplification tolerance in meters")
+
+	return cmd
+}
+
+func NewKMLCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "kml",
+		Short: "Convert fit file route to KML",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return route(cmd, args, "kml", fitcmd.WriteKML)
+		},
+	}
+
+	cmd.Flags().Float64("simplify", 0, "Douglas-Peucker simplification tolerance in meters")
+
+	return cmd
+}
+
+func route(cmd *cobra.Command, args []string, ext string, write routeWriter) error {
+	tolerance, _ := cmd.Flags().GetFloat64("simplify")
+	if tolerance < 0 {
+		return fmt.Errorf("simplify tolerance must not be negative: %v", tolerance)
+	}
+
+	for _, arg := range args {
+		file, err := os.Open(arg)
+		if err != nil {
+			return fmt.Errorf("open: %w", err)
+		}
+		defer file.Close()
+
+		data, err := fit.Decode(file)
+		if err != nil {
+			ignore, _ := cmd.Flags().GetBool("ignore-file-checksum")
+			_, ok := err.(fit.IntegrityError)
+			if !ignore || !ok {
+				return fmt.Errorf("decode: %w", err)
+			}
+		}
+
+		activity, err := fitcmd.Summarize(data, DefaultMeasurements, DefaultCorrelates, nil)
+		if err != nil {
+			return fmt.Errorf("summarize: %w", err)
+		}
+
+		// the route is buffered so that no file is created for activities
+		// without a route
+		buf := new(bytes.Buffer)
+		err = write(buf, data, activity, tolerance)
+		if errors.Is(err, fitcmd.ErrNoPositions) {
+			fmt.Fprintf(os.Stderr, "WARN: %s: %s\n", arg, err)
+			continue
+		} else if err != nil {
+			return fmt.Errorf("write %s: %w", ext, err)
+		}
+
+		routeFile := fmt.Sprintf("%s.%s", strings.TrimSuffix(path.Base(file.Name()), path.Ext(file.Name())), ext)
+		output, err := os.Create(routeFile)
+		if err != nil {
+			return fmt.Errorf("open: %w", err)
+		}
+		defer output.Close()
+
+		_, err = buf.WriteTo(output)
+		if err != nil {
+			return fmt.Errorf("write %s: %w", ext, err)
+		}
+	}
+
+	return nil
+}
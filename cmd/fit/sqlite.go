@@ -0,0 +1,702 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	fitcmd "github.com/subtlepseudonym/fit"
+
+	_ "github.com/mattn/go-sqlite3"
+	fit "github.com/subtlepseudonym/fit-go"
+)
+
+// sqliteSchema creates the import, activity, measurement, correlation, lap,
+// lap measurement, curve, heart rate zone, and import file tables along with
+// a table of raw record values, such that a single file holds everything
+// otherwise written to postgres and influx. Arrays and objects are stored as
+// JSON text.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS import (
+	id TEXT PRIMARY KEY,
+	start_time TEXT NOT NULL,
+	end_time TEXT,
+	device TEXT,
+	files TEXT,
+	skipped TEXT,
+	removed TEXT,
+	errors TEXT,
+	log TEXT
+);
+
+CREATE TABLE IF NOT EXISTS activity (
+	id TEXT PRIMARY KEY,
+	hash INTEGER NOT NULL UNIQUE,
+	import_id TEXT REFERENCES import (id),
+	type TEXT,
+	start_time TEXT,
+	end_time TEXT,
+	tags TEXT,
+	totals TEXT,
+	average_power REAL,
+	normalized_power REAL,
+	variability_index REAL,
+	functional_threshold_power REAL,
+	intensity_factor REAL,
+	training_stress_score REAL,
+	elapsed_time REAL,
+	timer_time REAL,
+	moving_time REAL,
+	pauses TEXT,
+	days TEXT
+);
+
+CREATE TABLE IF NOT EXISTS measurement (
+	id TEXT PRIMARY KEY,
+	activity_id TEXT NOT NULL REFERENCES activity (id),
+	name TEXT NOT NULL,
+	unit TEXT,
+	maximum REAL,
+	minimum REAL,
+	median REAL,
+	mean REAL,
+	variance REAL,
+	standard_deviation REAL,
+	time_weighted_mean REAL,
+	time_weighted_median REAL,
+	time_weighted_variance REAL,
+	time_weighted_standard_deviation REAL,
+	UNIQUE (activity_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS correlation (
+	id TEXT PRIMARY KEY,
+	activity_id TEXT NOT NULL REFERENCES activity (id),
+	measurement_a TEXT NOT NULL,
+	measurement_b TEXT NOT NULL,
+	correlation REAL
+);
+
+CREATE TABLE IF NOT EXISTS lap (
+	id TEXT PRIMARY KEY,
+	activity_id TEXT NOT NULL REFERENCES activity (id),
+	session INTEGER NOT NULL DEFAULT 0,
+	lap_index INTEGER NOT NULL,
+	sport TEXT,
+	start_time TEXT,
+	end_time TEXT,
+	UNIQUE (activity_id, session, lap_index)
+);
+
+CREATE TABLE IF NOT EXISTS lap_measurement (
+	id TEXT PRIMARY KEY,
+	lap_id TEXT NOT NULL REFERENCES lap (id),
+	name TEXT NOT NULL,
+	unit TEXT,
+	maximum REAL,
+	minimum REAL,
+	median REAL,
+	mean REAL,
+	variance REAL,
+	standard_deviation REAL,
+	time_weighted_mean REAL,
+	time_weighted_median REAL,
+	time_weighted_variance REAL,
+	time_weighted_standard_deviation REAL,
+	UNIQUE (lap_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS curve (
+	id TEXT PRIMARY KEY,
+	activity_id TEXT NOT NULL REFERENCES activity (id),
+	measurement TEXT NOT NULL,
+	unit TEXT,
+	duration INTEGER NOT NULL,
+	value REAL,
+	UNIQUE (activity_id, measurement, duration)
+);
+
+CREATE TABLE IF NOT EXISTS heart_rate_zone (
+	id TEXT PRIMARY KEY,
+	activity_id TEXT NOT NULL REFERENCES activity (id),
+	zone INTEGER NOT NULL,
+	minimum REAL,
+	maximum REAL,
+	duration REAL,
+	UNIQUE (activity_id, zone)
+);
+
+CREATE TABLE IF NOT EXISTS import_file (
+	id TEXT PRIMARY KEY,
+	checksum TEXT NOT NULL UNIQUE,
+	import_id TEXT REFERENCES import (id),
+	activity_id TEXT REFERENCES activity (id),
+	filename TEXT
+);
+
+CREATE TABLE IF NOT EXISTS record (
+	activity_id TEXT NOT NULL REFERENCES activity (id),
+	timestamp TEXT NOT NULL,
+	name TEXT NOT NULL,
+	value REAL NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS record_activity_id_timestamp ON record (activity_id, timestamp);
+`
+
+const sqliteInsertActivity = `
+INSERT INTO activity
+(
+	id,
+	hash,
+	import_id,
+	type,
+	start_time,
+	end_time,
+	tags,
+	totals,
+	average_power,
+	normalized_power,
+	variability_index,
+	functional_threshold_power,
+	intensity_factor,
+	training_stress_score,
+	elapsed_time,
+	timer_time,
+	moving_time,
+	pauses,
+	days
+) VALUES (
+	?, ?, ?, ?, ?, ?, ?, ?,
+	?, ?, ?, ?, ?, ?,
+	?, ?, ?, ?, ?
+) ON CONFLICT (hash)
+DO UPDATE SET
+	import_id = excluded.import_id,
+	type = excluded.type,
+	start_time = excluded.start_time,
+	end_time = excluded.end_time,
+	tags = excluded.tags,
+	totals = excluded.totals,
+	average_power = excluded.average_power,
+	normalized_power = excluded.normalized_power,
+	variability_index = excluded.variability_index,
+	functional_threshold_power = excluded.functional_threshold_power,
+	intensity_factor = excluded.intensity_factor,
+	training_stress_score = excluded.training_stress_score,
+	elapsed_time = excluded.elapsed_time,
+	timer_time = excluded.timer_time,
+	moving_time = excluded.moving_time,
+	pauses = excluded.pauses,
+	days = excluded.days
+RETURNING id;
+`
+
+const sqliteInsertMeasurement = `
+INSERT INTO measurement
+(
+	id,
+	activity_id,
+	name,
+	unit,
+	maximum,
+	minimum,
+	median,
+	mean,
+	variance,
+	standard_deviation,
+	time_weighted_mean,
+	time_weighted_median,
+	time_weighted_variance,
+	time_weighted_standard_deviation
+) VALUES (
+	?, ?, ?, ?,
+	?, ?, ?, ?, ?, ?,
+	?, ?, ?, ?
+);
+`
+
+const sqliteInsertCorrelation = `
+INSERT INTO correlation
+(
+	id,
+	activity_id,
+	measurement_a,
+	measurement_b,
+	correlation
+) VALUES (
+	?, ?, ?, ?, ?
+);
+`
+
+const sqliteInsertLap = `
+INSERT INTO lap
+(
+	id,
+	activity_id,
+	session,
+	lap_index,
+	sport,
+	start_time,
+	end_time
+) VALUES (
+	?, ?, ?, ?, ?, ?, ?
+);
+`
+
+const sqliteInsertLapMeasurement = `
+INSERT INTO lap_measurement
+(
+	id,
+	lap_id,
+	name,
+	unit,
+	maximum,
+	minimum,
+	median,
+	mean,
+	variance,
+	standard_deviation,
+	time_weighted_mean,
+	time_weighted_median,
+	time_weighted_variance,
+	time_weighted_standard_deviation
+) VALUES (
+	?, ?, ?, ?,
+	?, ?, ?, ?, ?, ?,
+	?, ?, ?, ?
+);
+`
+
+const sqliteInsertCurve = `
+INSERT INTO curve
+(
+	id,
+	activity_id,
+	measurement,
+	unit,
+	duration,
+	value
+) VALUES (
+	?, ?, ?, ?, ?, ?
+);
+`
+
+const sqliteInsertHeartRateZone = `
+INSERT INTO heart_rate_zone
+(
+	id,
+	activity_id,
+	zone,
+	minimum,
+	maximum,
+	duration
+) VALUES (
+	?, ?, ?, ?, ?, ?
+);
+`
+
+const sqliteInsertImportFile = `
+INSERT INTO import_file
+(
+	id,
+	checksum,
+	import_id,
+	activity_id,
+	filename
+) VALUES (
+	?, ?, ?, ?, ?
+) ON CONFLICT (checksum)
+DO UPDATE SET
+	import_id = excluded.import_id,
+	activity_id = excluded.activity_id,
+	filename = excluded.filename;
+`
+
+const sqliteInsertRecord = `
+INSERT INTO record (activity_id, timestamp, name, value) VALUES (?, ?, ?, ?);
+`
+
+// sqliteStorage writes summaries and raw records to a single SQLite file
+type sqliteStorage struct {
+	db        *sql.DB
+	tx        *sql.Tx
+	elevation fitcmd.AccumulatorOption
+
+	// set by WriteSummary for associating records with the activity
+	activityID   string
+	activityType string
+}
+
+// openSQLiteStorage opens the database at filename, creating it and its
+// tables if they do not exist
+func openSQLiteStorage(filename string) (*sqliteStorage, error) {
+	db, err := sql.Open("sqlite3", filename)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite open: %w", err)
+	}
+
+	// sqlite permits a single writer, so reads from concurrent extraction
+	// wait for the loading transaction rather than failing as locked
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(sqliteSchema)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create sqlite tables: %w", err)
+	}
+
+	return &sqliteStorage{
+		db:        db,
+		elevation: fitcmd.WithHysteresis(fitcmd.DefaultAltitudeHysteresis),
+	}, nil
+}
+
+func (s *sqliteStorage) InsertImport(start time.Time, device string) (string, error) {
+	importID, err := scruGenerator.Generate()
+	if err != nil {
+		return "", fmt.Errorf("generate import ID: %w", err)
+	}
+
+	_, err = s.db.Exec(
+		`INSERT INTO import (id, start_time, device) VALUES (?, ?, ?);`,
+		importID.String(),
+		start.Format(time.RFC3339),
+		device,
+	)
+	return importID.String(), err
+}
+
+func (s *sqliteStorage) UpdateImport(record importRecord) error {
+	args := []interface{}{record.End.Format(time.RFC3339)}
+	for _, list := range [][]string{record.Files, record.Skipped, record.Removed, record.Errors} {
+		if list == nil {
+			args = append(args, nil)
+			continue
+		}
+		b, err := json.Marshal(list)
+		if err != nil {
+			return fmt.Errorf("marshal json: %w", err)
+		}
+		args = append(args, string(b))
+	}
+	args = append(args, record.Log, record.ID)
+
+	_, err := s.db.Exec(`
+UPDATE import SET
+	end_time = ?,
+	files = ?,
+	skipped = ?,
+	removed = ?,
+	errors = ?,
+	log = ?
+WHERE id = ?;
+`, args...)
+	return err
+}
+
+func (s *sqliteStorage) FileImported(checksum string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM import_file WHERE checksum = ?);`, checksum).Scan(&exists)
+	return exists, err
+}
+
+func (s *sqliteStorage) RecordFile(source fitcmd.Source) error {
+	id, err := scruGenerator.Generate()
+	if err != nil {
+		return fmt.Errorf("generate scru ID: %w", err)
+	}
+
+	_, err = s.db.Exec(sqliteInsertImportFile, id.String(), source.Checksum, source.ImportID, nil, source.Filename)
+	return err
+}
+
+func (s *sqliteStorage) Begin() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin sql transaction: %w", err)
+	}
+	s.tx = tx
+	s.activityID, s.activityType = "", ""
+	return nil
+}
+
+// WriteSummary inserts or updates the activity, replacing the measurements,
+// correlations, laps, curves, heart rate zones, and records of a re-imported
+// activity, then records the file as imported
+func (s *sqliteStorage) WriteSummary(activity *fitcmd.Activity, source fitcmd.Source) error {
+	tx := s.tx
+
+	// activity arguments are shared with postgres
+	activityQuery, err := buildActivityQuery("activity", activity, source.ImportID)
+	if err != nil {
+		return fmt.Errorf("build activity query: %w", err)
+	}
+
+	var activityID string
+	err = tx.QueryRow(sqliteInsertActivity, activityQuery.Args...).Scan(&activityID)
+	if err != nil {
+		return fmt.Errorf("insert activity: %w", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM lap_measurement WHERE lap_id IN (SELECT id FROM lap WHERE activity_id = ?);`, activityID)
+	if err != nil {
+		return fmt.Errorf("delete previous lap_measurement rows: %w", err)
+	}
+	for _, table := range []string{"measurement", "correlation", "lap", "curve", "heart_rate_zone", "record"} {
+		_, err = tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE activity_id = ?;`, table), activityID)
+		if err != nil {
+			return fmt.Errorf("delete previous %s rows: %w", table, err)
+		}
+	}
+
+	queries := make([]query, 0, len(activity.Measurements)+len(activity.Correlations)+1)
+	for _, m := range activity.Measurements {
+		id, err := scruGenerator.Generate()
+		if err != nil {
+			return fmt.Errorf("generate scru ID: %w", err)
+		}
+
+		queries = append(queries, query{
+			SQL: sqliteInsertMeasurement,
+			Args: []interface{}{
+				id.String(),
+				activityID,
+				m.Name,
+				m.Unit,
+				m.Maximum,
+				m.Minimum,
+				m.Median,
+				m.Mean,
+				m.Variance,
+				m.StandardDeviation,
+				timeWeighted(m, m.TimeWeightedMean),
+				timeWeighted(m, m.TimeWeightedMedian),
+				timeWeighted(m, m.TimeWeightedVariance),
+				timeWeighted(m, m.TimeWeightedStandardDeviation),
+			},
+		})
+	}
+
+	for _, c := range activity.Correlations {
+		id, err := scruGenerator.Generate()
+		if err != nil {
+			return fmt.Errorf("generate scru ID: %w", err)
+		}
+
+		queries = append(queries, query{
+			SQL: sqliteInsertCorrelation,
+			Args: []interface{}{
+				id.String(),
+				activityID,
+				c.MeasurementA,
+				c.MeasurementB,
+				c.Correlation,
+			},
+		})
+	}
+
+	for _, session := range []bool{false, true} {
+		laps := activity.Laps
+		if session {
+			laps = activity.Sessions
+		}
+
+		for _, lap := range laps {
+			lapID, err := scruGenerator.Generate()
+			if err != nil {
+				return fmt.Errorf("generate scru ID: %w", err)
+			}
+
+			var sport interface{}
+			if lap.Sport != "" {
+				sport = lap.Sport
+			}
+
+			queries = append(queries, query{
+				SQL: sqliteInsertLap,
+				Args: []interface{}{
+					lapID.String(),
+					activityID,
+					session,
+					lap.Index,
+					sport,
+					lap.StartTime.Format(time.RFC3339),
+					lap.EndTime.Format(time.RFC3339),
+				},
+			})
+
+			for _, m := range lap.Measurements {
+				id, err := scruGenerator.Generate()
+				if err != nil {
+					return fmt.Errorf("generate scru ID: %w", err)
+				}
+
+				queries = append(queries, query{
+					SQL: sqliteInsertLapMeasurement,
+					Args: []interface{}{
+						id.String(),
+						lapID.String(),
+						m.Name,
+						m.Unit,
+						m.Maximum,
+						m.Minimum,
+						m.Median,
+						m.Mean,
+						m.Variance,
+						m.StandardDeviation,
+						timeWeighted(m, m.TimeWeightedMean),
+						timeWeighted(m, m.TimeWeightedMedian),
+						timeWeighted(m, m.TimeWeightedVariance),
+						timeWeighted(m, m.TimeWeightedStandardDeviation),
+					},
+				})
+			}
+		}
+	}
+
+	for _, curve := range activity.Curves {
+		for _, point := range curve.Points {
+			id, err := scruGenerator.Generate()
+			if err != nil {
+				return fmt.Errorf("generate scru ID: %w", err)
+			}
+
+			queries = append(queries, query{
+				SQL: sqliteInsertCurve,
+				Args: []interface{}{
+					id.String(),
+					activityID,
+					curve.Measurement,
+					curve.Unit,
+					point.Duration,
+					point.Value,
+				},
+			})
+		}
+	}
+
+	for _, zone := range activity.HeartRateZones {
+		id, err := scruGenerator.Generate()
+		if err != nil {
+			return fmt.Errorf("generate scru ID: %w", err)
+		}
+
+		queries = append(queries, query{
+			SQL: sqliteInsertHeartRateZone,
+			Args: []interface{}{
+				id.String(),
+				activityID,
+				zone.Zone,
+				zone.Minimum,
+				nullIfZero(zone.Maximum),
+				zone.Duration,
+			},
+		})
+	}
+
+	fileID, err := scruGenerator.Generate()
+	if err != nil {
+		return fmt.Errorf("generate scru ID: %w", err)
+	}
+	queries = append(queries, query{
+		SQL: sqliteInsertImportFile,
+		Args: []interface{}{
+			fileID.String(),
+			source.Checksum,
+			source.ImportID,
+			activityID,
+			source.Filename,
+		},
+	})
+
+	err = execQueries(tx, queries)
+	if err != nil {
+		return err
+	}
+
+	s.activityID, s.activityType = activityID, activity.Type
+	return nil
+}
+
+// WriteRecords writes one row per set value of each measurement that applies
+// to the activity type. Monitoring files are summarized, but their records
+// are not stored.
+func (s *sqliteStorage) WriteRecords(data *fit.File, tags map[string]string) error {
+	if data.Type() != fit.FileTypeActivity {
+		return nil
+	}
+	if s.activityID == "" {
+		return errors.New("records written before summary")
+	}
+
+	activityData, err := data.Activity()
+	if err != nil {
+		return fmt.Errorf("activity: %w", err)
+	}
+
+	stmt, err := s.tx.Prepare(sqliteInsertRecord)
+	if err != nil {
+		return fmt.Errorf("prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	acc := fitcmd.NewAccumulator(s.elevation)
+	registry := acc.Registry()
+	measurements := make(map[string]struct{})
+	for _, m := range registry.Names(s.activityType) {
+		measurements[m] = struct{}{}
+	}
+
+	var timestamp string
+	add := func(key string, value interface{}) {
+		if _, ok := measurements[key]; !ok || err != nil {
+			return
+		}
+		v, ok := fitcmd.ToFloat64(value)
+		if !ok || math.IsNaN(v) || registry.IsUnset(key, v) {
+			return
+		}
+		_, err = stmt.Exec(s.activityID, timestamp, key, v)
+	}
+
+	for _, record := range activityData.Records {
+		timestamp = record.Timestamp.UTC().Format(time.RFC3339)
+
+		var readErr error
+		acc, readErr = fitcmd.ReadRecord(acc, record, add)
+		if readErr != nil {
+			return fmt.Errorf("read record: %w", readErr)
+		}
+		if err != nil {
+			return fmt.Errorf("insert: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *sqliteStorage) Commit() error {
+	err := s.tx.Commit()
+	s.tx = nil
+	if err != nil {
+		return fmt.Errorf("commit sql: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStorage) Rollback() error {
+	if s.tx == nil {
+		return nil
+	}
+	err := s.tx.Rollback()
+	s.tx = nil
+	return err
+}
+
+func (s *sqliteStorage) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	fitcmd "github.com/subtlepseudonym/fit"
+)
+
+func TestSQLiteWriteSummaryReplacesRows(t *testing.T) {
+	s, err := openSQLiteStorage(filepath.Join(t.TempDir(), "fit.db"))
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+	defer s.Close()
+
+	start := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+	measurement := func() []*fitcmd.Measurement {
+		return []*fitcmd.Measurement{{Name: "heart_rate", Unit: "bpm", Maximum: 150}}
+	}
+	activity := &fitcmd.Activity{
+		Type:      "multisport",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		Laps: []*fitcmd.LapSummary{
+			{Index: 0, StartTime: start, EndTime: start.Add(30 * time.Minute), Measurements: measurement()},
+			{Index: 1, StartTime: start.Add(30 * time.Minute), EndTime: start.Add(time.Hour), Measurements: measurement()},
+		},
+		Sessions: []*fitcmd.LapSummary{
+			{Index: 0, Sport: "running", StartTime: start, EndTime: start.Add(time.Hour), Measurements: measurement()},
+		},
+		Curves: []*fitcmd.Curve{
+			{Measurement: "heart_rate", Unit: "bpm", Points: []*fitcmd.CurvePoint{{Duration: 1, Value: 150}, {Duration: 5, Value: 148}}},
+		},
+		HeartRateZones: []*fitcmd.ZoneTime{
+			{Zone: 1, Minimum: 0, Maximum: 120, Duration: 600},
+			{Zone: 2, Minimum: 120, Duration: 3000},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		err = s.Begin()
+		if err != nil {
+			t.Fatalf("begin: %s", err)
+		}
+		err = s.WriteSummary(activity, fitcmd.Source{Checksum: "checksum", Filename: "multisport.fit"})
+		if err != nil {
+			t.Fatalf("write summary: %s", err)
+		}
+		err = s.Commit()
+		if err != nil {
+			t.Fatalf("commit: %s", err)
+		}
+
+		// rows missing from the re-imported activity are removed
+		activity.Curves[0].Points = activity.Curves[0].Points[:1]
+		activity.HeartRateZones = activity.HeartRateZones[:1]
+	}
+
+	counts := map[string]int{
+		"activity":        1,
+		"lap":             3,
+		"lap_measurement": 3,
+		"curve":           1,
+		"heart_rate_zone": 1,
+	}
+	for table, expected := range counts {
+		var count int
+		err = s.db.QueryRow("SELECT COUNT(*) FROM " + table + ";").Scan(&count)
+		if err != nil {
+			t.Fatalf("count %s: %s", table, err)
+		}
+		if count != expected {
+			t.Errorf("%s: got %d rows, expected %d", table, count, expected)
+		}
+	}
+
+	var sport string
+	err = s.db.QueryRow(`SELECT sport FROM lap WHERE session;`).Scan(&sport)
+	if err != nil {
+		t.Fatalf("select session: %s", err)
+	}
+	if sport != "running" {
+		t.Errorf("session sport: got %q, expected %q", sport, "running")
+	}
+}
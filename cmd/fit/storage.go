@@ -0,0 +1,232 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	fitcmd "github.com/subtlepseudonym/fit"
+
+	_ "github.com/lib/pq"
+	"github.com/spf13/pflag"
+	fit "github.com/subtlepseudonym/fit-go"
+)
+
+// storage is a sink that also records import runs and the checksums of
+// imported files. Implementations must be safe for concurrent calls to
+// FileImported.
+type storage interface {
+	fitcmd.Sink
+	// InsertImport records the start of an import run and returns its ID
+	InsertImport(start time.Time, device string) (string, error)
+	// UpdateImport records the results of an import run
+	UpdateImport(record importRecord) error
+	// FileImported returns whether a file with the given checksum has been
+	// successfully imported
+	FileImported(checksum string) (bool, error)
+	// RecordFile records a file without an activity, such as one without
+	// records, as imported so that it is skipped by later runs
+	RecordFile(source fitcmd.Source) error
+}
+
+// enabledSinks returns whether postgres and influx are enabled by flags.
+// They are enabled unless disabled with --no-postgres or --no-influx, or if
+// --sqlite is set without their connection flags. Sqlite is enabled if
+// --sqlite is set.
+func enabledSinks(flags *pflag.FlagSet) (usePostgres, useInflux bool, err error) {
+	sqlitePath, _ := flags.GetString("sqlite")
+	postgresDSN, _ := flags.GetString("postgres")
+	influxHost, _ := flags.GetString("influx-host")
+	influxToken, _ := flags.GetString("influx-token")
+	noPostgres, _ := flags.GetBool("no-postgres")
+	noInflux, _ := flags.GetBool("no-influx")
+
+	usePostgres = !noPostgres && (postgresDSN != "" || sqlitePath == "")
+	if usePostgres && postgresDSN == "" {
+		return false, false, errors.New("required flag \"postgres\" not set, use --no-postgres to disable")
+	}
+
+	useInflux = !noInflux && (influxHost != "" || sqlitePath == "")
+	if useInflux && (influxHost == "" || influxToken == "") {
+		return false, false, errors.New("required flags \"influx-host\" and \"influx-token\" not set, use --no-influx to disable")
+	}
+
+	if !usePostgres && sqlitePath == "" {
+		return false, false, errors.New("either postgres or sqlite is required for recording imports")
+	}
+
+	return usePostgres, useInflux, nil
+}
+
+// newSinks returns the sinks enabled by flags, as described by enabledSinks,
+// and the storage used for recording import runs. Imports are recorded in
+// postgres if it is enabled and otherwise in sqlite.
+func newSinks(flags *pflag.FlagSet) (storage, []fitcmd.Sink, error) {
+	usePostgres, useInflux, err := enabledSinks(flags)
+	if err != nil {
+		return nil, nil, err
+	}
+	sqlitePath, _ := flags.GetString("sqlite")
+
+	var imports storage
+	var sinks []fitcmd.Sink
+	closeSinks := func() {
+		for _, sink := range sinks {
+			sink.Close()
+		}
+	}
+
+	// influx is committed first, as it does not support transactions and
+	// a failed write should prevent the summary from being committed
+	if useInflux {
+		sink, err := newInfluxSink(flags)
+		if err != nil {
+			return nil, nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if usePostgres {
+		sink, err := openPostgresStorage(flags)
+		if err != nil {
+			closeSinks()
+			return nil, nil, err
+		}
+		sinks = append(sinks, sink)
+		imports = sink
+	}
+
+	if sqlitePath != "" {
+		elevation, err := elevationFromFlags(flags)
+		if err != nil {
+			closeSinks()
+			return nil, nil, err
+		}
+
+		sink, err := openSQLiteStorage(sqlitePath)
+		if err != nil {
+			closeSinks()
+			return nil, nil, err
+		}
+		sink.elevation = elevation
+		sinks = append(sinks, sink)
+		if imports == nil {
+			imports = sink
+		}
+	}
+
+	return imports, sinks, nil
+}
+
+// postgresStorage writes summaries to postgres. Records are not stored.
+type postgresStorage struct {
+	db     *sql.DB
+	tables tables
+	tx     *sql.Tx
+}
+
+func openPostgresStorage(flags *pflag.FlagSet) (*postgresStorage, error) {
+	t, err := tablesFromFlags(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	postgresDSN, _ := flags.GetString("postgres")
+	db, err := sql.Open("postgres", postgresDSN)
+	if err != nil {
+		return nil, fmt.Errorf("sql open: %w", err)
+	}
+
+	return &postgresStorage{
+		db:     db,
+		tables: t,
+	}, nil
+}
+
+func (s *postgresStorage) InsertImport(start time.Time, device string) (string, error) {
+	return insertImport(s.db, s.tables.Import, start, device)
+}
+
+func (s *postgresStorage) UpdateImport(record importRecord) error {
+	return updateImport(s.db, s.tables.Import, record)
+}
+
+func (s *postgresStorage) FileImported(checksum string) (bool, error) {
+	return fileImported(s.db, s.tables.ImportFile, checksum)
+}
+
+func (s *postgresStorage) RecordFile(source fitcmd.Source) error {
+	q, err := buildImportFileQuery(s.tables.ImportFile, source.Checksum, source.ImportID, "", source.Filename)
+	if err != nil {
+		return fmt.Errorf("build import file query: %w", err)
+	}
+
+	_, err = s.db.Exec(q.SQL, q.Args...)
+	return err
+}
+
+func (s *postgresStorage) Begin() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin sql transaction: %w", err)
+	}
+	s.tx = tx
+	return nil
+}
+
+// WriteSummary inserts or updates the activity and its measurements,
+// correlations, laps, curves, and heart rate zones, then records the file as
+// imported
+func (s *postgresStorage) WriteSummary(activity *fitcmd.Activity, source fitcmd.Source) error {
+	t := s.tables
+	activityQuery, err := buildActivityQuery(t.Activity, activity, source.ImportID)
+	if err != nil {
+		return fmt.Errorf("build activity query: %w", err)
+	}
+
+	var activityID string
+	err = s.tx.QueryRow(activityQuery.SQL, activityQuery.Args...).Scan(&activityID)
+	if err != nil {
+		return fmt.Errorf("insert activity: %w", err)
+	}
+
+	queries, err := buildQueries(t, activityID, activity)
+	if err != nil {
+		return fmt.Errorf("build activity detail queries: %w", err)
+	}
+
+	fileQuery, err := buildImportFileQuery(t.ImportFile, source.Checksum, source.ImportID, activityID, source.Filename)
+	if err != nil {
+		return fmt.Errorf("build import file query: %w", err)
+	}
+	queries = append(queries, fileQuery)
+
+	return execQueries(s.tx, queries)
+}
+
+func (s *postgresStorage) WriteRecords(data *fit.File, tags map[string]string) error {
+	return nil
+}
+
+func (s *postgresStorage) Commit() error {
+	err := s.tx.Commit()
+	s.tx = nil
+	if err != nil {
+		return fmt.Errorf("commit sql: %w", err)
+	}
+	return nil
+}
+
+func (s *postgresStorage) Rollback() error {
+	if s.tx == nil {
+		return nil
+	}
+	err := s.tx.Rollback()
+	s.tx = nil
+	return err
+}
+
+func (s *postgresStorage) Close() error {
+	return s.db.Close()
+}
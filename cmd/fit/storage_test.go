@@ -0,0 +1,113 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNewSinks(t *testing.T) {
+	postgres := []string{"--postgres", "postgres://localhost/fit"}
+	influx := []string{"--influx-host", "http://localhost:8086", "--influx-token", "token"}
+
+	tests := []struct {
+		name    string
+		args    []string
+		sqlite  bool
+		sinks   []string
+		imports string
+		err     bool
+	}{
+		{name: "none", err: true},
+		{name: "postgres without influx", args: postgres, err: true},
+		{name: "postgres and influx", args: append(postgres, influx...), sinks: []string{"influx", "postgres"}, imports: "postgres"},
+		{name: "postgres no influx", args: append(postgres, "--no-influx"), sinks: []string{"postgres"}, imports: "postgres"},
+		{name: "sqlite", sqlite: true, sinks: []string{"sqlite"}, imports: "sqlite"},
+		{name: "sqlite and postgres", sqlite: true, args: postgres, sinks: []string{"postgres", "sqlite"}, imports: "postgres"},
+		{name: "sqlite and influx", sqlite: true, args: influx, sinks: []string{"influx", "sqlite"}, imports: "sqlite"},
+		{name: "sqlite no postgres", sqlite: true, args: append(postgres, "--no-postgres"), sinks: []string{"sqlite"}, imports: "sqlite"},
+		{name: "no postgres without sqlite", args: append(influx, "--no-postgres"), err: true},
+		{name: "sqlite negative hysteresis", sqlite: true, args: []string{"--altitude-hysteresis", "-1"}, err: true},
+		{name: "influx host without token", sqlite: true, args: []string{"--influx-host", "http://localhost:8086"}, err: true},
+	}
+
+	sinkName := func(sink interface{}) string {
+		switch sink.(type) {
+		case *postgresStorage:
+			return "postgres"
+		case *sqliteStorage:
+			return "sqlite"
+		case *influxSink:
+			return "influx"
+		}
+		return "unknown"
+	}
+
+	for _, test := range tests {
+		args := test.args
+		if test.sqlite {
+			args = append([]string{"--sqlite", filepath.Join(t.TempDir(), "fit.db")}, args...)
+		}
+
+		cmd := NewETLCommand()
+		err := cmd.ParseFlags(args)
+		if err != nil {
+			t.Fatalf("%s: parse flags: %s", test.name, err)
+		}
+
+		imports, sinks, err := newSinks(cmd.Flags())
+		if (err != nil) != test.err {
+			t.Errorf("%s: got error %v, expected error %t", test.name, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		var names []string
+		for _, sink := range sinks {
+			names = append(names, sinkName(sink))
+			sink.Close()
+		}
+		if len(names) != len(test.sinks) {
+			t.Errorf("%s: got sinks %v, expected %v", test.name, names, test.sinks)
+		} else {
+			for i := range names {
+				if names[i] != test.sinks[i] {
+					t.Errorf("%s: got sinks %v, expected %v", test.name, names, test.sinks)
+					break
+				}
+			}
+		}
+		if name := sinkName(imports); name != test.imports {
+			t.Errorf("%s: got imports %s, expected %s", test.name, name, test.imports)
+		}
+	}
+}
+
+func TestETLSetupSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fit.db")
+	cmd, _, err := NewETLCommand().Find([]string{"setup"})
+	if err != nil {
+		t.Fatalf("find setup command: %s", err)
+	}
+	err = cmd.ParseFlags([]string{"--sqlite", path})
+	if err != nil {
+		t.Fatalf("parse flags: %s", err)
+	}
+
+	err = etlSetup(cmd, nil)
+	if err != nil {
+		t.Fatalf("setup: %s", err)
+	}
+
+	s, err := openSQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+	defer s.Close()
+
+	imported, err := s.FileImported("checksum")
+	if err != nil || imported {
+		t.Errorf("file imported: got %t, %v", imported, err)
+	}
+}
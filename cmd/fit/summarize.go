@@ -12,12 +12,18 @@ import (
 )
 
 var DefaultMeasurements = []string{
+	"active_time",
 	"altitude",
 	"cadence",
+	"calories",
 	"distance",
+	"grade",
 	"heart_rate",
 	"moving_speed",
+	"power",
+	"smoothed_altitude",
 	"speed",
+	"steps",
 	"temperature",
 	"vicenty_distance",
 }
@@ -36,11 +42,19 @@ func NewSummarizeCommand() *cobra.Command {
 	}
 
 	cmd.Flags().String("device", DefaultDevice, "Telemetry device name")
+	cmd.Flags().Float64("ftp", 0, "Functional threshold power in watts for calculating training load")
+	addZoneFlags(cmd.Flags())
+	addElevationFlags(cmd.Flags())
 
 	return cmd
 }
 
 func summarize(cmd *cobra.Command, args []string) error {
+	elevation, err := elevationFromFlags(cmd.Flags())
+	if err != nil {
+		return err
+	}
+
 	for _, arg := range args {
 		file, err := os.Open(arg)
 		if err != nil {
@@ -70,11 +84,22 @@ func summarize(cmd *cobra.Command, args []string) error {
 			tags["ignore-file-checksum"] = "true"
 		}
 
-		activity, err := fitcmd.Summarize(data, DefaultMeasurements, DefaultCorrelates, tags)
+		activity, err := fitcmd.Summarize(data, DefaultMeasurements, DefaultCorrelates, tags, elevation)
 		if err != nil {
 			return fmt.Errorf("summarize: %w", err)
 		}
 
+		ftp, _ := cmd.Flags().GetFloat64("ftp")
+		if activity.Power != nil {
+			activity.Power.CalculateTrainingLoad(ftp)
+		}
+
+		zones, err := zonesFromFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+		activity.HeartRateZones = activity.CalculateTimeInZones("heart_rate", zones)
+
 		b, err := json.Marshal(activity)
 		if err != nil {
 			return fmt.Errorf("json marshal: %w", err)
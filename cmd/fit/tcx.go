@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	fitcmd "github.com/subtlepseudonym/fit"
+
+	"github.com/spf13/cobra"
+	fit "github.com/subtlepseudonym/fit-go"
+)
+
+func NewTCXCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "tcx",
+		Short: "Convert fit file to Training Center XML",
+		RunE:  tcx,
+	}
+}
+
+func tcx(cmd *cobra.Command, args []string) error {
+	for _, arg := range args {
+		file, err := os.Open(arg)
+		if err != nil {
+			return fmt.Errorf("open: %w", err)
+		}
+		defer file.Close()
+
+		data, err := fit.Decode(file)
+		if err != nil {
+			ignore, _ := cmd.Flags().GetBool("ignore-file-checksum")
+			_, ok := err.(fit.IntegrityError)
+			if !ignore || !ok {
+				return fmt.Errorf("decode: %w", err)
+			}
+		}
+
+		tcxFile := fmt.Sprintf("%s.tcx", strings.TrimSuffix(path.Base(file.Name()), path.Ext(file.Name())))
+		output, err := os.Create(tcxFile)
+		if err != nil {
+			return fmt.Errorf("open: %w", err)
+		}
+		defer output.Close()
+
+		err = fitcmd.WriteTCX(output, data)
+		if err != nil {
+			return fmt.Errorf("write tcx: %w", err)
+		}
+	}
+
+	return nil
+}
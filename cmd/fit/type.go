@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"text/tabwriter"
 
 	fitcmd "github.com/subtlepseudonym/fit"
 
@@ -11,11 +12,15 @@ import (
 )
 
 func NewTypeCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "type",
 		Short: "Display fit file type information",
 		RunE:  fitType,
 	}
+
+	cmd.Flags().Bool("explain", false, "Display the sport information and rule used to determine type")
+
+	return cmd
 }
 
 func fitType(cmd *cobra.Command, args []string) error {
@@ -39,9 +44,40 @@ func fitType(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("type: %w", err)
 		}
 
-		fmt.Println(t)
+		if explain, _ := cmd.Flags().GetBool("explain"); !explain {
+			fmt.Println(t)
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(w, "type:\t%s\n", t.Type)
+		fmt.Fprintf(w, "name:\t%s\n", t.Name)
+		fmt.Fprintf(w, "sport:\t%s\n", t.Sport)
+		fmt.Fprintf(w, "sub_sport:\t%s\n", t.SubSport)
+		fmt.Fprintf(w, "rule:\t%s\n", explainRule(t))
+
+		err = w.Flush()
+		if err != nil {
+			return fmt.Errorf("flush: %w", err)
+		}
 		return nil
 	}
 
 	return nil
 }
+
+// explainRule describes the rule used to determine the activity type
+func explainRule(t fitcmd.ActivityType) string {
+	switch t.Rule {
+	case fitcmd.RuleName:
+		return fmt.Sprintf("name %q", t.Name)
+	case fitcmd.RuleSubSport:
+		return fmt.Sprintf("sub_sport %s", t.SubSport)
+	case fitcmd.RuleSport:
+		return fmt.Sprintf("sport %s", t.Sport)
+	case fitcmd.RuleFileType:
+		return "file type"
+	}
+
+	return "no matching rule"
+}
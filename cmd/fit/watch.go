@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// isFitFile returns whether the filename has a FIT file extension
+func isFitFile(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".fit")
+}
+
+// listFitFiles returns the paths of all FIT files in dir
+func listFitFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !isFitFile(entry.Name()) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+
+	return files, nil
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+	sent    bool
+}
+
+// sendWhenStable sends each file once its size and modification time are
+// unchanged between two consecutive checks, so that files still being
+// written are not sent. Files removed before they are stable are dropped.
+func sendWhenStable(ctx context.Context, files []string, interval time.Duration, written chan<- string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	states := make(map[string]*fileState, len(files))
+	for len(files) > 0 {
+		remaining := make([]string, 0, len(files))
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil {
+				continue
+			}
+
+			state, ok := states[file]
+			if !ok || state.size != info.Size() || !state.modTime.Equal(info.ModTime()) {
+				states[file] = &fileState{size: info.Size(), modTime: info.ModTime()}
+				remaining = append(remaining, file)
+				continue
+			}
+
+			select {
+			case written <- file:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		files = remaining
+		if len(files) == 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollDir sends the path of each FIT file in dir, including those present
+// when polling starts, once its size and modification time are unchanged
+// between two consecutive polls
+func pollDir(ctx context.Context, dir string, interval time.Duration, written chan<- string) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	states := make(map[string]*fileState)
+	for {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("read directory: %w", err)
+		}
+
+		seen := make(map[string]struct{}, len(entries))
+		for _, entry := range entries {
+			if entry.IsDir() || !isFitFile(entry.Name()) {
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				// file removed since directory was read
+				continue
+			}
+
+			name := entry.Name()
+			seen[name] = struct{}{}
+			state, ok := states[name]
+			if !ok || state.size != info.Size() || !state.modTime.Equal(info.ModTime()) {
+				states[name] = &fileState{size: info.Size(), modTime: info.ModTime()}
+				continue
+			}
+			if state.sent {
+				continue
+			}
+
+			select {
+			case written <- filepath.Join(dir, name):
+				state.sent = true
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		for name := range states {
+			if _, ok := seen[name]; !ok {
+				delete(states, name)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// etlWatch runs the pipeline on batches of files as they are written to
+// dir until interrupted. Files already present in dir are sent by the
+// watcher once they are no longer being written, so that files written
+// while the watcher starts are not missed.
+func etlWatch(p *pipeline, dir string) error {
+	flags := p.cmd.Flags()
+	interval, _ := flags.GetDuration("watch-interval")
+	delay, _ := flags.GetDuration("watch-delay")
+	verbose, _ := flags.GetBool("verbose")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	written := make(chan string)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- watchDir(ctx, dir, interval, written)
+	}()
+
+	batch := make(map[string]struct{})
+
+	// a batch is run once no new files have been written for the delay
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-watchErr:
+			if err != nil {
+				return fmt.Errorf("watch: %w", err)
+			}
+			return nil
+		case file := <-written:
+			batch[file] = struct{}{}
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(delay)
+		case <-timer.C:
+			if len(batch) == 0 {
+				continue
+			}
+
+			files := make([]string, 0, len(batch))
+			for file := range batch {
+				files = append(files, file)
+			}
+			sort.Strings(files)
+			batch = make(map[string]struct{})
+
+			importID, err := p.Run(files)
+			if err != nil {
+				fmt.Println("ERR: import:", importID, err)
+			} else if verbose {
+				fmt.Println("imported", len(files), "files")
+			}
+		}
+	}
+}
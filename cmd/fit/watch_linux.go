@@ -0,0 +1,83 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchDir sends the path of each FIT file in dir once it has been closed
+// after writing or moved into dir. Files present once the watch is added are
+// sent when they are no longer being written; a file written during startup
+// may be sent twice. Falls back to polling if inotify is unavailable.
+func watchDir(ctx context.Context, dir string, interval time.Duration, written chan<- string) error {
+	fd, err := unix.InotifyInit1(unix.IN_NONBLOCK | unix.IN_CLOEXEC)
+	if err != nil {
+		return pollDir(ctx, dir, interval, written)
+	}
+
+	_, err = unix.InotifyAddWatch(fd, dir, unix.IN_CLOSE_WRITE|unix.IN_MOVED_TO)
+	if err != nil {
+		unix.Close(fd)
+		return pollDir(ctx, dir, interval, written)
+	}
+
+	// non-blocking file descriptors use the runtime poller, so closing the
+	// file unblocks pending reads
+	file := os.NewFile(uintptr(fd), "inotify")
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		file.Close()
+	}()
+
+	// existing files are listed after the watch is added so that no file
+	// is missed between listing and watching
+	existing, err := listFitFiles(dir)
+	if err != nil {
+		return err
+	}
+	existingCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go sendWhenStable(existingCtx, existing, interval, written)
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("read inotify events: %w", err)
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + unix.SizeofInotifyEvent
+			offset = nameStart + int(event.Len)
+
+			name := strings.TrimRight(string(buf[nameStart:offset]), "\x00")
+			if event.Mask&unix.IN_ISDIR != 0 || !isFitFile(name) {
+				continue
+			}
+
+			select {
+			case written <- filepath.Join(dir, name):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// watchDir sends the path of each FIT file in dir once it has finished
+// being written
+func watchDir(ctx context.Context, dir string, interval time.Duration, written chan<- string) error {
+	return pollDir(ctx, dir, interval, written)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// receiveFiles collects files sent on written until none arrive for wait
+func receiveFiles(written <-chan string, wait time.Duration) []string {
+	var files []string
+	for {
+		select {
+		case file := <-written:
+			files = append(files, file)
+		case <-time.After(wait):
+			sort.Strings(files)
+			return files
+		}
+	}
+}
+
+func TestPollDirSendsEachFileOnce(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.fit")
+	err := os.WriteFile(existing, []byte("existing"), 0644)
+	if err != nil {
+		t.Fatalf("write existing file: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	written := make(chan string)
+	done := make(chan error, 1)
+	go func() {
+		done <- pollDir(ctx, dir, 10*time.Millisecond, written)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	created := filepath.Join(dir, "created.fit")
+	err = os.WriteFile(created, []byte("created"), 0644)
+	if err != nil {
+		t.Fatalf("write created file: %s", err)
+	}
+
+	files := receiveFiles(written, 200*time.Millisecond)
+	expected := []string{created, existing}
+	if len(files) != len(expected) || files[0] != expected[0] || files[1] != expected[1] {
+		t.Errorf("got %v, expected %v", files, expected)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("poll: %s", err)
+	}
+}
+
+func TestWatchDirSendsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.fit")
+	err := os.WriteFile(existing, []byte("existing"), 0644)
+	if err != nil {
+		t.Fatalf("write existing file: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	written := make(chan string)
+	done := make(chan error, 1)
+	go func() {
+		done <- watchDir(ctx, dir, 10*time.Millisecond, written)
+	}()
+
+	select {
+	case file := <-written:
+		if file != existing {
+			t.Errorf("got %s, expected %s", file, existing)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("existing file not sent")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("watch: %s", err)
+	}
+}
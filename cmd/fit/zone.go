@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	fitcmd "github.com/subtlepseudonym/fit"
+
+	"github.com/spf13/pflag"
+)
+
+// addZoneFlags adds flags for configuring heart rate zones
+func addZoneFlags(flags *pflag.FlagSet) {
+	flags.Float64("max-heart-rate", 0, "Maximum heart rate for calculating heart rate zones")
+	flags.Float64("threshold-heart-rate", 0, "Lactate threshold heart rate for calculating heart rate zones")
+	flags.Float64Slice("heart-rate-zones", nil, "Explicit heart rate zone lower bounds in beats per minute")
+}
+
+// zonesFromFlags returns the configured heart rate zones, or nil if zones
+// are not configured. Explicit bounds take precedence over threshold heart
+// rate, which takes precedence over maximum heart rate.
+func zonesFromFlags(flags *pflag.FlagSet) (fitcmd.Zones, error) {
+	if bounds, _ := flags.GetFloat64Slice("heart-rate-zones"); len(bounds) > 0 {
+		zones, err := fitcmd.NewZones(bounds)
+		if err != nil {
+			return nil, fmt.Errorf("heart rate zones: %w", err)
+		}
+		return zones, nil
+	}
+
+	if threshold, _ := flags.GetFloat64("threshold-heart-rate"); threshold > 0 {
+		return fitcmd.NewThresholdHeartRateZones(threshold), nil
+	}
+
+	if max, _ := flags.GetFloat64("max-heart-rate"); max > 0 {
+		return fitcmd.NewMaxHeartRateZones(max), nil
+	}
+
+	return nil, nil
+}
+
+// zoneTagFromFlags returns the heart rate zones used to tag records, or nil
+// if zone tagging is not requested. Requesting zone tags without configuring
+// heart rate zones is an error.
+func zoneTagFromFlags(flags *pflag.FlagSet) (fitcmd.Zones, error) {
+	if zoneTag, _ := flags.GetBool("zone-tag"); !zoneTag {
+		return nil, nil
+	}
+
+	zones, err := zonesFromFlags(flags)
+	if err != nil {
+		return nil, err
+	}
+	if zones == nil {
+		return nil, fmt.Errorf("zone tag requires heart rate zone flags")
+	}
+
+	return zones, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestZoneTagRequiresZones(t *testing.T) {
+	tests := []struct {
+		args  []string
+		zones bool
+		err   bool
+	}{
+		{args: nil},
+		{args: []string{"--max-heart-rate", "190"}},
+		{args: []string{"--zone-tag"}, err: true},
+		{args: []string{"--zone-tag", "--max-heart-rate", "190"}, zones: true},
+		{args: []string{"--zone-tag", "--heart-rate-zones", "120,100"}, err: true},
+	}
+
+	for _, test := range tests {
+		lineFlags := NewLineCommand().Flags()
+		etlCmd := NewETLCommand()
+		etlArgs := append([]string{"--influx-host", "http://localhost:8086", "--influx-token", "token"}, test.args...)
+
+		err := lineFlags.Parse(test.args)
+		if err != nil {
+			t.Fatalf("%v: parse line flags: %s", test.args, err)
+		}
+		err = etlCmd.ParseFlags(etlArgs)
+		if err != nil {
+			t.Fatalf("%v: parse etl flags: %s", test.args, err)
+		}
+
+		zones, err := zoneTagFromFlags(lineFlags)
+		if (err != nil) != test.err || (zones != nil) != test.zones {
+			t.Errorf("%v: line: got zones %v, error %v", test.args, zones, err)
+		}
+
+		sink, err := newInfluxSink(etlCmd.Flags())
+		if (err != nil) != test.err {
+			t.Errorf("%v: etl: got error %v", test.args, err)
+		}
+		if sink != nil {
+			if (sink.zones != nil) != test.zones {
+				t.Errorf("%v: etl: got zones %v", test.args, sink.zones)
+			}
+			sink.Close()
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package fit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/subtlepseudonym/fit-go"
+)
+
+// TimestampColumn is the record timestamp column name
+const TimestampColumn = "timestamp"
+
+// WriteCSV writes one row per activity record with the provided columns,
+// which may be the timestamp or any registered measurement. If no columns
+// are provided, the timestamp and all measurements that apply to the
+// activity type are written. Unset values are left blank. Options configure
+// the accumulator used to read records.
+func WriteCSV(out io.Writer, data *fit.File, columns []string, comma rune, options ...AccumulatorOption) error {
+	records, err := activityRecords(data)
+	if err != nil {
+		return err
+	}
+
+	acc := NewAccumulator(options...)
+	if len(columns) == 0 {
+		activityType, err := Type(data)
+		if err != nil {
+			return fmt.Errorf("type: %w", err)
+		}
+		columns = append([]string{TimestampColumn}, acc.registry.Names(activityType.Type)...)
+	}
+
+	index := make(map[string]int, len(columns))
+	for i, column := range columns {
+		if _, ok := acc.registry.Lookup(column); !ok && column != TimestampColumn {
+			return fmt.Errorf("unknown column: %q", column)
+		}
+		index[column] = i
+	}
+
+	writer := csv.NewWriter(out)
+	writer.Comma = comma
+	err = writer.Write(columns)
+	if err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	row := make([]string, len(columns))
+	add := func(key string, value interface{}) {
+		i, ok := index[key]
+		if !ok {
+			return
+		}
+		row[i] = formatValue(acc.registry, key, value)
+	}
+
+	for _, record := range records {
+		for i := range row {
+			row[i] = ""
+		}
+		if i, ok := index[TimestampColumn]; ok {
+			row[i] = record.Timestamp.UTC().Format(time.RFC3339)
+		}
+
+		acc, err = ReadRecord(acc, record, add)
+		if err != nil {
+			return fmt.Errorf("read record: %w", err)
+		}
+
+		err = writer.Write(row)
+		if err != nil {
+			return fmt.Errorf("write row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		return fmt.Errorf("flush: %w", err)
+	}
+
+	return nil
+}
+
+// formatValue returns the value as a string, or an empty string if the
+// value is unset
+func formatValue(registry *MeasurementRegistry, key string, value interface{}) string {
+	val, ok := ToFloat64(value)
+	if !ok || registry.IsUnset(key, val) {
+		return ""
+	}
+	if val == math.Trunc(val) && math.Abs(val) < 1e15 {
+		return strconv.FormatInt(int64(val), 10)
+	}
+	return strconv.FormatFloat(val, 'f', -1, 64)
+}
+
+// ToFloat64 converts numeric measurement values, as passed to an AddFunc, to
+// float64, returning false for non-numeric values
+func ToFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+
+	return 0, false
+}
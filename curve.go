@@ -0,0 +1,148 @@
+package fit
+
+import (
+	"time"
+)
+
+// DefaultCurveDurations are the window durations for which best efforts
+// are calculated
+var DefaultCurveDurations = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	20 * time.Minute,
+	60 * time.Minute,
+}
+
+// DefaultCurveMeasurements are the measurements for which best effort
+// curves are calculated
+var DefaultCurveMeasurements = []string{
+	"heart_rate",
+	"power",
+	"speed",
+}
+
+// curveMaxGap is the longest gap between samples, in seconds, across which
+// the previous value is held when calculating curves. Series are split at
+// longer gaps so that pauses don't lower best efforts.
+const curveMaxGap = 10
+
+// Curve contains the highest average value sustained over each duration
+type Curve struct {
+	Measurement string        `json:"measurement"`
+	Unit        string        `json:"unit"`
+	Points      []*CurvePoint `json:"points"`
+}
+
+type CurvePoint struct {
+	Duration int     `json:"duration"` // seconds
+	Value    float64 `json:"value"`
+}
+
+// CalculateCurves calculates best effort curves for the provided
+// measurements from per-record values
+func (a *Activity) CalculateCurves(measurements []string, durations []time.Duration) []*Curve {
+	curves := make([]*Curve, 0, len(measurements))
+	for _, name := range measurements {
+		m, ok := a.mmap[name]
+		if !ok || len(m.values) != len(a.timestamps) {
+			continue
+		}
+
+		samples := make([]sample, 0, len(m.values))
+		for i, v := range m.values {
+			if v >= float64(m.unset) {
+				continue
+			}
+			samples = append(samples, sample{a.timestamps[i], v})
+		}
+
+		curve := calculateCurve(resampleSegments(samples, curveMaxGap), durations)
+		if len(curve) == 0 {
+			continue
+		}
+
+		curves = append(curves, &Curve{
+			Measurement: name,
+			Unit:        m.Unit,
+			Points:      curve,
+		})
+	}
+
+	return curves
+}
+
+// resampleSegments splits samples at gaps longer than maxGap seconds and
+// converts each run of samples to a one second series. Samples with a
+// timestamp earlier than the preceding sample are dropped.
+func resampleSegments(samples []sample, maxGap int) [][]float64 {
+	var segments [][]float64
+	var segment []sample
+	for _, s := range samples {
+		if len(segment) > 0 {
+			last := segment[len(segment)-1].timestamp
+			if s.timestamp.Before(last) {
+				continue
+			}
+			if int(s.timestamp.Sub(last)/time.Second) > maxGap {
+				segments = append(segments, resample(segment, maxGap))
+				segment = nil
+			}
+		}
+		segment = append(segment, s)
+	}
+	if len(segment) > 0 {
+		segments = append(segments, resample(segment, maxGap))
+	}
+
+	return segments
+}
+
+// calculateCurve finds the highest rolling average of the one second series
+// segments for each duration. Windows don't span segments, so durations
+// longer than every segment are omitted.
+func calculateCurve(segments [][]float64, durations []time.Duration) []*CurvePoint {
+	points := make([]*CurvePoint, 0, len(durations))
+	for _, duration := range durations {
+		window := int(duration / time.Second)
+		if window < 1 {
+			continue
+		}
+
+		var found bool
+		var best float64
+		for _, series := range segments {
+			if window > len(series) {
+				continue
+			}
+
+			var sum float64
+			for _, v := range series[:window] {
+				sum += v
+			}
+
+			if !found || sum > best {
+				best = sum
+				found = true
+			}
+			for i := window; i < len(series); i++ {
+				sum += series[i] - series[i-window]
+				if sum > best {
+					best = sum
+				}
+			}
+		}
+		if !found {
+			continue
+		}
+
+		points = append(points, &CurvePoint{
+			Duration: window,
+			Value:    best / float64(window),
+		})
+	}
+
+	return points
+}
@@ -0,0 +1,35 @@
+package fit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCalculateCurveSplitsAtGaps(t *testing.T) {
+	start := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	// 30 seconds at 200, a one minute pause, then 30 seconds at 100
+	var samples []sample
+	for i := 0; i < 30; i++ {
+		samples = append(samples, sample{start.Add(time.Duration(i) * time.Second), 200})
+	}
+	for i := 90; i < 120; i++ {
+		samples = append(samples, sample{start.Add(time.Duration(i) * time.Second), 100})
+	}
+
+	segments := resampleSegments(samples, curveMaxGap)
+	if len(segments) != 2 || len(segments[0]) != 30 || len(segments[1]) != 30 {
+		t.Fatalf("segments: got %d, expected 2 of 30 seconds", len(segments))
+	}
+
+	durations := []time.Duration{5 * time.Second, 30 * time.Second, time.Minute}
+	points := calculateCurve(segments, durations)
+	expected := []*CurvePoint{
+		{Duration: 5, Value: 200},
+		{Duration: 30, Value: 200},
+	}
+	if !reflect.DeepEqual(points, expected) {
+		t.Errorf("got %+v, expected %+v", points, expected)
+	}
+}
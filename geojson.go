@@ -0,0 +1,88 @@
+package fit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/subtlepseudonym/fit-go"
+)
+
+type geoJSONFeatureCollection struct {
+	Type     string            `json:"type"`
+	Features []*geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string       `json:"type"`
+	Coordinates [][2]float64 `json:"coordinates"`
+}
+
+// WriteGeoJSON writes the activity route as a feature collection containing
+// a single LineString feature. Per-point values are written as property
+// arrays aligned with the coordinates and the activity, if provided, as the
+// summary property. Routes are simplified if tolerance, in meters, is
+// positive.
+func WriteGeoJSON(out io.Writer, data *fit.File, activity *Activity, tolerance float64) error {
+	records, err := activityRecords(data)
+	if err != nil {
+		return err
+	}
+
+	points, err := routePoints(records)
+	if err != nil {
+		return err
+	}
+	points = simplifyRoute(points, tolerance)
+
+	coordinates := make([][2]float64, 0, len(points))
+	times := make([]string, 0, len(points))
+	heartRates := make([]*float64, 0, len(points))
+	speeds := make([]*float64, 0, len(points))
+	altitudes := make([]*float64, 0, len(points))
+	for _, point := range points {
+		coordinates = append(coordinates, [2]float64{point.Longitude, point.Latitude})
+		times = append(times, point.Time.UTC().Format(time.RFC3339))
+		heartRates = append(heartRates, nullIfNaN(point.HeartRate))
+		speeds = append(speeds, nullIfNaN(point.Speed))
+		altitudes = append(altitudes, nullIfNaN(point.Altitude))
+	}
+
+	properties := map[string]interface{}{
+		"time":       times,
+		"heart_rate": heartRates,
+		"speed":      speeds,
+		"altitude":   altitudes,
+	}
+	if activity != nil {
+		properties["summary"] = activity
+	}
+
+	collection := geoJSONFeatureCollection{
+		Type: "FeatureCollection",
+		Features: []*geoJSONFeature{
+			{
+				Type: "Feature",
+				Geometry: geoJSONGeometry{
+					Type:        "LineString",
+					Coordinates: coordinates,
+				},
+				Properties: properties,
+			},
+		},
+	}
+
+	err = json.NewEncoder(out).Encode(collection)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	return nil
+}
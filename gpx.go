@@ -0,0 +1,159 @@
+package fit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/subtlepseudonym/fit-go"
+)
+
+const (
+	gpxNamespace                = "http://www.topografix.com/GPX/1/1"
+	gpxTrackPointExtensionSpace = "http://www.garmin.com/xmlschemas/TrackPointExtension/v1"
+)
+
+type gpx struct {
+	XMLName  xml.Name    `xml:"gpx"`
+	Version  string      `xml:"version,attr"`
+	Creator  string      `xml:"creator,attr"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Gpxtpx   string      `xml:"xmlns:gpxtpx,attr"`
+	Metadata gpxMetadata `xml:"metadata"`
+	Tracks   []gpxTrack  `xml:"trk"`
+}
+
+type gpxMetadata struct {
+	Time string `xml:"time"`
+}
+
+type gpxTrack struct {
+	Type     string             `xml:"type,omitempty"`
+	Segments []*gpxTrackSegment `xml:"trkseg"`
+}
+
+type gpxTrackSegment struct {
+	Points []gpxTrackPoint `xml:"trkpt"`
+}
+
+type gpxTrackPoint struct {
+	Latitude   string                  `xml:"lat,attr"`
+	Longitude  string                  `xml:"lon,attr"`
+	Elevation  string                  `xml:"ele,omitempty"`
+	Time       string                  `xml:"time"`
+	Extensions *gpxTrackPointExtension `xml:"extensions>gpxtpx:TrackPointExtension,omitempty"`
+}
+
+// gpxTrackPointExtension fields must be ordered according to the
+// TrackPointExtension schema
+type gpxTrackPointExtension struct {
+	Temperature *int8  `xml:"gpxtpx:atemp,omitempty"`
+	HeartRate   *uint8 `xml:"gpxtpx:hr,omitempty"`
+	Cadence     *uint8 `xml:"gpxtpx:cad,omitempty"`
+}
+
+// WriteGPX writes activity records with positions as a GPX 1.1 track,
+// starting a new track segment after each pause
+func WriteGPX(out io.Writer, data *fit.File) error {
+	if data.Type() != fit.FileTypeActivity {
+		return fmt.Errorf("unsupported file type: %d", data.Type())
+	}
+
+	activityType, err := Type(data)
+	if err != nil {
+		return fmt.Errorf("type: %w", err)
+	}
+
+	activityData, err := data.Activity()
+	if err != nil {
+		return fmt.Errorf("activity: %w", err)
+	}
+
+	records := activityData.Records
+	if len(records) == 0 {
+		return ErrNoRecords
+	}
+
+	track := gpxTrack{Type: activityType.Type}
+	var segment *gpxTrackSegment
+	for _, span := range splitAtPauses(records, activityData.Events) {
+		segment = nil
+		for _, record := range span {
+			lat, lon := record.PositionLat.Degrees(), record.PositionLong.Degrees()
+			if math.IsNaN(lat) || math.IsNaN(lon) {
+				continue
+			}
+
+			if segment == nil {
+				segment = new(gpxTrackSegment)
+				track.Segments = append(track.Segments, segment)
+			}
+			segment.Points = append(segment.Points, newGPXTrackPoint(record, lat, lon))
+		}
+	}
+
+	doc := gpx{
+		Version: "1.1",
+		Creator: "fit",
+		Xmlns:   gpxNamespace,
+		Gpxtpx:  gpxTrackPointExtensionSpace,
+		Metadata: gpxMetadata{
+			Time: records[0].Timestamp.UTC().Format(time.RFC3339),
+		},
+		Tracks: []gpxTrack{track},
+	}
+
+	_, err = io.WriteString(out, xml.Header)
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(doc)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	_, err = io.WriteString(out, "\n")
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	return nil
+}
+
+func newGPXTrackPoint(record *fit.RecordMsg, lat, lon float64) gpxTrackPoint {
+	point := gpxTrackPoint{
+		Latitude:  strconv.FormatFloat(lat, 'f', -1, 64),
+		Longitude: strconv.FormatFloat(lon, 'f', -1, 64),
+		Time:      record.Timestamp.UTC().Format(time.RFC3339),
+	}
+
+	if altitude := record.GetEnhancedAltitudeScaled(); !math.IsNaN(altitude) {
+		// altitude is recorded with 0.2 meter resolution
+		point.Elevation = strconv.FormatFloat(altitude, 'f', 1, 64)
+	}
+
+	var ext gpxTrackPointExtension
+	if !IsUnset("temperature", float64(record.Temperature)) {
+		temperature := record.Temperature
+		ext.Temperature = &temperature
+	}
+	if !IsUnset("heart_rate", float64(record.HeartRate)) {
+		heartRate := record.HeartRate
+		ext.HeartRate = &heartRate
+	}
+	if !IsUnset("cadence", float64(record.Cadence)) {
+		cadence := record.Cadence
+		ext.Cadence = &cadence
+	}
+	if ext != (gpxTrackPointExtension{}) {
+		point.Extensions = &ext
+	}
+
+	return point
+}
@@ -4,12 +4,21 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strconv"
 
 	lp "github.com/influxdata/line-protocol/v2/lineprotocol"
 	"github.com/subtlepseudonym/fit-go"
 )
 
+// EncodeFunc returns an AddFunc adding the provided measurements as line
+// fields, omitting values unset in DefaultRegistry
 func EncodeFunc(encoder *lp.Encoder, measurements map[string]struct{}) AddFunc {
+	return DefaultRegistry.EncodeFunc(encoder, measurements)
+}
+
+// EncodeFunc returns an AddFunc adding the provided measurements as line
+// fields, omitting unset values
+func (r *MeasurementRegistry) EncodeFunc(encoder *lp.Encoder, measurements map[string]struct{}) AddFunc {
 	return func(key string, value interface{}) {
 		if _, ok := measurements[key]; !ok {
 			return
@@ -18,57 +27,57 @@ func EncodeFunc(encoder *lp.Encoder, measurements map[string]struct{}) AddFunc {
 		var val lp.Value
 		switch v := value.(type) {
 		case uint:
-			if IsUnset(key, float64(v)) {
+			if r.IsUnset(key, float64(v)) {
 				return
 			}
 			val = lp.UintValue(uint64(v))
 		case uint8:
-			if IsUnset(key, float64(v)) {
+			if r.IsUnset(key, float64(v)) {
 				return
 			}
 			val = lp.UintValue(uint64(v))
 		case uint16:
-			if IsUnset(key, float64(v)) {
+			if r.IsUnset(key, float64(v)) {
 				return
 			}
 			val = lp.UintValue(uint64(v))
 		case uint32:
-			if IsUnset(key, float64(v)) {
+			if r.IsUnset(key, float64(v)) {
 				return
 			}
 			val = lp.UintValue(uint64(v))
 		case uint64:
-			if IsUnset(key, float64(v)) {
+			if r.IsUnset(key, float64(v)) {
 				return
 			}
 			val = lp.UintValue(v)
 		case int:
-			if IsUnset(key, float64(v)) {
+			if r.IsUnset(key, float64(v)) {
 				return
 			}
 			val = lp.IntValue(int64(v))
 		case int8:
-			if IsUnset(key, float64(v)) {
+			if r.IsUnset(key, float64(v)) {
 				return
 			}
 			val = lp.IntValue(int64(v))
 		case int16:
-			if IsUnset(key, float64(v)) {
+			if r.IsUnset(key, float64(v)) {
 				return
 			}
 			val = lp.IntValue(int64(v))
 		case int32:
-			if IsUnset(key, float64(v)) {
+			if r.IsUnset(key, float64(v)) {
 				return
 			}
 			val = lp.IntValue(int64(v))
 		case int64:
-			if IsUnset(key, float64(v)) {
+			if r.IsUnset(key, float64(v)) {
 				return
 			}
 			val = lp.IntValue(v)
 		case float32:
-			if IsUnset(key, float64(v)) {
+			if r.IsUnset(key, float64(v)) {
 				return
 			}
 			if v, ok := lp.FloatValue(float64(v)); ok {
@@ -77,7 +86,7 @@ func EncodeFunc(encoder *lp.Encoder, measurements map[string]struct{}) AddFunc {
 				return
 			}
 		case float64:
-			if IsUnset(key, v) {
+			if r.IsUnset(key, v) {
 				return
 			}
 			if v, ok := lp.FloatValue(v); ok {
@@ -93,37 +102,48 @@ func EncodeFunc(encoder *lp.Encoder, measurements map[string]struct{}) AddFunc {
 	}
 }
 
+// HeartRateZoneTag is the tag key used to label records with their heart
+// rate zone
+const HeartRateZoneTag = "heart_rate_zone"
+
 func WriteLineProtocol(out io.Writer, data *fit.File, tags map[string]string) error {
+	return WriteLineProtocolWithZones(out, data, tags, nil)
+}
+
+// WriteLineProtocolWithZones writes line protocol, tagging activity records
+// with their heart rate zone if zones are provided. Accumulator options
+// configure measurements derived from multiple records.
+func WriteLineProtocolWithZones(out io.Writer, data *fit.File, tags map[string]string, zones Zones, options ...AccumulatorOption) error {
 	switch data.Type() {
 	case fit.FileTypeActivity:
-		fitType, err := Type(data)
+		activityType, err := Type(data)
 		if err != nil {
 			return fmt.Errorf("type: %w", err)
 		}
+		fitType := activityType.Type
 
 		activityData, err := data.Activity()
 		if err != nil {
 			return fmt.Errorf("activity: %w", err)
 		}
 
+		acc := NewAccumulator(options...)
 		measurements := make(map[string]struct{})
-		for m := range DefaultMeasurements {
+		for _, m := range acc.registry.Names(fitType) {
 			measurements[m] = struct{}{}
 		}
-		if fitType != TypeMonitoring && fitType != TypeTracking {
-			for m := range DefaultSportMeasurements {
-				measurements[m] = struct{}{}
-			}
+
+		// Line protocol requires tags to be added in lexical order
+		lineTags := make(map[string]string, len(tags)+1)
+		for key, value := range tags {
+			lineTags[key] = value
 		}
-		if fitType == TypeCycling {
-			for m := range DefaultCyclingMeasurements {
-				measurements[m] = struct{}{}
-			}
+		if zones != nil {
+			lineTags[HeartRateZoneTag] = ""
 		}
 
-		// Line protocol requires tags to be added in lexical order
-		tagKeys := make([]string, 0, len(tags))
-		for key, _ := range tags {
+		tagKeys := make([]string, 0, len(lineTags))
+		for key, _ := range lineTags {
 			tagKeys = append(tagKeys, key)
 		}
 		sort.Strings(tagKeys)
@@ -131,13 +151,24 @@ func WriteLineProtocol(out io.Writer, data *fit.File, tags map[string]string) er
 		var encoder lp.Encoder
 		encoder.SetPrecision(lp.Second)
 
-		encode := EncodeFunc(&encoder, measurements)
-		acc := new(Accumulator)
+		encode := acc.registry.EncodeFunc(&encoder, measurements)
 		for _, record := range activityData.Records {
+			if zones != nil {
+				if acc.registry.IsUnset("heart_rate", float64(record.HeartRate)) {
+					lineTags[HeartRateZoneTag] = ""
+				} else {
+					lineTags[HeartRateZoneTag] = strconv.Itoa(zones.Zone(float64(record.HeartRate)))
+				}
+			}
+
 			encoder.StartLine(fitType)
 
 			for _, key := range tagKeys {
-				encoder.AddTag(key, tags[key])
+				// empty tag values are not permitted
+				if lineTags[key] == "" {
+					continue
+				}
+				encoder.AddTag(key, lineTags[key])
 			}
 
 			acc, err = ReadRecord(acc, record, encode)
@@ -151,6 +182,71 @@ func WriteLineProtocol(out io.Writer, data *fit.File, tags map[string]string) er
 			}
 		}
 
+		_, err = out.Write(encoder.Bytes())
+		if err != nil {
+			return fmt.Errorf("write: %w", err)
+		}
+	case fit.FileTypeMonitoringA, fit.FileTypeMonitoringB, fit.FileTypeMonitoringDaily:
+		msgs, err := monitorings(data)
+		if err != nil {
+			return err
+		}
+
+		registry := NewAccumulator(options...).registry
+		measurements := make(map[string]struct{})
+		for _, m := range registry.Names(TypeMonitoring) {
+			measurements[m] = struct{}{}
+		}
+
+		// Line protocol requires tags to be added in lexical order
+		lineTags := make(map[string]string, len(tags)+1)
+		for key, value := range tags {
+			lineTags[key] = value
+		}
+		lineTags["activity_type"] = ""
+
+		tagKeys := make([]string, 0, len(lineTags))
+		for key := range lineTags {
+			tagKeys = append(tagKeys, key)
+		}
+		sort.Strings(tagKeys)
+
+		var encoder lp.Encoder
+		encoder.SetPrecision(lp.Second)
+
+		encode := registry.EncodeFunc(&encoder, measurements)
+		acc := NewMonitoringAccumulator()
+		for _, msg := range msgs {
+			// lines require at least one field, so values are collected
+			// before starting the line
+			var keys []string
+			var values []interface{}
+			acc, err = ReadMonitoring(acc, msg, func(key string, value interface{}) {
+				keys = append(keys, key)
+				values = append(values, value)
+			})
+			if err != nil {
+				return fmt.Errorf("read monitoring: %w", err)
+			}
+			if len(keys) == 0 || fit.IsBaseTime(msg.Timestamp) {
+				continue
+			}
+
+			lineTags["activity_type"] = MonitoringActivityType(msg)
+			encoder.StartLine(TypeMonitoring)
+			for _, key := range tagKeys {
+				encoder.AddTag(key, lineTags[key])
+			}
+			for i := range keys {
+				encode(keys[i], values[i])
+			}
+
+			encoder.EndLine(msg.Timestamp)
+			if err = encoder.Err(); err != nil {
+				return fmt.Errorf("encoder: %w", err)
+			}
+		}
+
 		_, err = out.Write(encoder.Bytes())
 		if err != nil {
 			return fmt.Errorf("write: %w", err)
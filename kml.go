@@ -0,0 +1,145 @@
+package fit
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/subtlepseudonym/fit-go"
+)
+
+const kmlNamespace = "http://www.opengis.net/kml/2.2"
+
+type kml struct {
+	XMLName  xml.Name    `xml:"kml"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name      string       `xml:"name"`
+	Placemark kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name         string    `xml:"name"`
+	ExtendedData []kmlData `xml:"ExtendedData>Data,omitempty"`
+	Tessellate   int       `xml:"LineString>tessellate"`
+	Coordinates  string    `xml:"LineString>coordinates"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+// WriteKML writes the activity route as a KML LineString placemark with the
+// activity summary, if provided, as extended data. Routes are simplified if
+// tolerance, in meters, is positive.
+func WriteKML(out io.Writer, data *fit.File, activity *Activity, tolerance float64) error {
+	records, err := activityRecords(data)
+	if err != nil {
+		return err
+	}
+
+	points, err := routePoints(records)
+	if err != nil {
+		return err
+	}
+	points = simplifyRoute(points, tolerance)
+
+	coordinates := make([]string, 0, len(points))
+	for _, point := range points {
+		coordinate := strconv.FormatFloat(point.Longitude, 'f', -1, 64) + "," + strconv.FormatFloat(point.Latitude, 'f', -1, 64)
+		if v := nullIfNaN(point.Altitude); v != nil {
+			coordinate += "," + strconv.FormatFloat(*v, 'f', 1, 64)
+		}
+		coordinates = append(coordinates, coordinate)
+	}
+
+	name := records[0].Timestamp.UTC().Format(time.RFC3339)
+	var extendedData []kmlData
+	if activity != nil {
+		name = fmt.Sprintf("%s %s", activity.Type, name)
+		extendedData, err = kmlSummaryData(activity)
+		if err != nil {
+			return err
+		}
+	}
+
+	doc := kml{
+		Xmlns: kmlNamespace,
+		Document: kmlDocument{
+			Name: name,
+			Placemark: kmlPlacemark{
+				Name:         name,
+				ExtendedData: extendedData,
+				Tessellate:   1,
+				Coordinates:  strings.Join(coordinates, " "),
+			},
+		},
+	}
+
+	_, err = io.WriteString(out, xml.Header)
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(doc)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	_, err = io.WriteString(out, "\n")
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	return nil
+}
+
+// kmlSummaryData flattens the activity's scalar summary values, including
+// those nested in objects such as totals and tags, into named data values.
+// Lists such as measurements and laps are omitted.
+func kmlSummaryData(activity *Activity) ([]kmlData, error) {
+	b, err := json.Marshal(activity)
+	if err != nil {
+		return nil, fmt.Errorf("marshal summary: %w", err)
+	}
+
+	var summary map[string]interface{}
+	err = json.Unmarshal(b, &summary)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal summary: %w", err)
+	}
+
+	var data []kmlData
+	var flatten func(prefix string, values map[string]interface{})
+	flatten = func(prefix string, values map[string]interface{}) {
+		for key, value := range values {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				flatten(prefix+key+".", v)
+			case string:
+				data = append(data, kmlData{prefix + key, v})
+			case float64:
+				data = append(data, kmlData{prefix + key, strconv.FormatFloat(v, 'f', -1, 64)})
+			case bool:
+				data = append(data, kmlData{prefix + key, strconv.FormatBool(v)})
+			}
+		}
+	}
+	flatten("", summary)
+
+	sort.Slice(data, func(i, j int) bool {
+		return data[i].Name < data[j].Name
+	})
+	return data, nil
+}
@@ -0,0 +1,109 @@
+package fit
+
+import (
+	"sort"
+	"time"
+
+	"github.com/subtlepseudonym/fit-go"
+)
+
+// LapSummary contains measurements calculated from the records that fall
+// within a single lap or session time window
+type LapSummary struct {
+	Index        int            `json:"index"`
+	Sport        string         `json:"sport,omitempty"`
+	StartTime    time.Time      `json:"start_time"`
+	EndTime      time.Time      `json:"end_time"`
+	Measurements []*Measurement `json:"measurements"`
+
+	mmap       map[string]*Measurement `json:"-"`
+	timestamps []time.Time             `json:"-"`
+	acc        *Accumulator            `json:"-"`
+}
+
+func NewLapSummary(index int, start, end time.Time, activityType string, options ...AccumulatorOption) *LapSummary {
+	acc := NewAccumulator(options...)
+	return &LapSummary{
+		Index:        index,
+		StartTime:    start,
+		EndTime:      end,
+		Measurements: make([]*Measurement, 0, 8),
+		mmap:         acc.registry.measurementMap(activityType),
+		acc:          acc,
+	}
+}
+
+// Contains returns whether the timestamp falls within the lap time window
+func (l *LapSummary) Contains(timestamp time.Time) bool {
+	return !timestamp.Before(l.StartTime) && !timestamp.After(l.EndTime)
+}
+
+func (l *LapSummary) AddValue(key string, value interface{}) {
+	addValue(l.mmap, key, value)
+}
+
+// ReadRecord adds the record's values to the lap measurements if the record
+// falls within the lap time window
+func (l *LapSummary) ReadRecord(record *fit.RecordMsg) error {
+	if !l.Contains(record.Timestamp) {
+		return nil
+	}
+
+	var err error
+	l.acc, err = ReadRecord(l.acc, record, l.AddValue)
+	l.timestamps = append(l.timestamps, record.Timestamp)
+	return err
+}
+
+func (l *LapSummary) FinalizeMeasurements(measurements []string) []*Measurement {
+	l.Measurements = finalizeMeasurements(l.mmap, measurements, l.Measurements, l.timestamps)
+	return l.Measurements
+}
+
+func newLapSummaries(laps []*fit.LapMsg, activityType string, options ...AccumulatorOption) []*LapSummary {
+	summaries := make([]*LapSummary, 0, len(laps))
+	for _, lap := range laps {
+		if lap == nil || lap.StartTime.IsZero() || lap.Timestamp.IsZero() {
+			continue
+		}
+		summaries = append(summaries, NewLapSummary(len(summaries), lap.StartTime, lap.Timestamp, activityType, options...))
+	}
+
+	sortSummaries(summaries)
+	return summaries
+}
+
+func newSessionSummaries(sessions []*fit.SessionMsg, activityType string, options ...AccumulatorOption) []*LapSummary {
+	summaries := make([]*LapSummary, 0, len(sessions))
+	for _, session := range sessions {
+		if session == nil || session.StartTime.IsZero() || session.Timestamp.IsZero() {
+			continue
+		}
+
+		// sessions within a multisport activity may differ from the
+		// activity's sport
+		sessionType := activityType
+		if session.Sport == fit.SportCycling {
+			sessionType = TypeCycling
+		}
+
+		summary := NewLapSummary(len(summaries), session.StartTime, session.Timestamp, sessionType, options...)
+		if session.Sport != fit.SportInvalid {
+			summary.Sport = session.Sport.String()
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sortSummaries(summaries)
+	return summaries
+}
+
+// sortSummaries orders summaries by start time and re-indexes them
+func sortSummaries(summaries []*LapSummary) {
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].StartTime.Before(summaries[j].StartTime)
+	})
+	for i, summary := range summaries {
+		summary.Index = i
+	}
+}
@@ -3,6 +3,7 @@ package fit
 import (
 	"math"
 	"sort"
+	"time"
 )
 
 type Measurement struct {
@@ -16,6 +17,15 @@ type Measurement struct {
 	Variance          float64 `json:"variance"`
 	StandardDeviation float64 `json:"standard_deviation"`
 
+	// Time-weighted aggregates weight each value by the interval until the
+	// next record, correcting for irregular recording intervals. They are
+	// only set if TimeWeighted is true.
+	TimeWeighted                  bool    `json:"time_weighted"`
+	TimeWeightedMean              float64 `json:"time_weighted_mean"`
+	TimeWeightedMedian            float64 `json:"time_weighted_median"`
+	TimeWeightedVariance          float64 `json:"time_weighted_variance"`
+	TimeWeightedStandardDeviation float64 `json:"time_weighted_standard_deviation"`
+
 	unset  uint      `json:"-"`
 	count  uint      `json:"-"`
 	sum    float64   `json:"-"`
@@ -69,6 +79,68 @@ func (m *Measurement) Finalize() (*Measurement, bool) {
 	return m, len(valid) > 0
 }
 
+// FinalizeTimeWeighted calculates time-weighted aggregates using the record
+// timestamps corresponding to each value. Intervals longer than maxGap are
+// assumed to be pauses and are not counted. TimeWeighted is set and true
+// returned if the aggregates were calculated.
+func (m *Measurement) FinalizeTimeWeighted(timestamps []time.Time, maxGap time.Duration) bool {
+	if !m.Valid() || len(timestamps) != len(m.values) {
+		return false
+	}
+
+	type weighted struct {
+		value  float64
+		weight float64
+	}
+
+	valid := make([]weighted, 0, len(m.values))
+	var sum, totalWeight float64
+	for i := 0; i < len(m.values)-1; i++ {
+		v := m.values[i]
+		if v >= float64(m.unset) {
+			continue
+		}
+
+		interval := timestamps[i+1].Sub(timestamps[i])
+		if interval <= 0 || interval > maxGap {
+			continue
+		}
+
+		w := interval.Seconds()
+		valid = append(valid, weighted{v, w})
+		sum += v * w
+		totalWeight += w
+	}
+	if totalWeight == 0 {
+		return false
+	}
+
+	m.TimeWeightedMean = sum / totalWeight
+
+	var ss float64
+	for _, v := range valid {
+		deviation := v.value - m.TimeWeightedMean
+		ss += v.weight * deviation * deviation
+	}
+	m.TimeWeightedVariance = ss / totalWeight
+	m.TimeWeightedStandardDeviation = math.Sqrt(m.TimeWeightedVariance)
+
+	sort.Slice(valid, func(i, j int) bool {
+		return valid[i].value < valid[j].value
+	})
+	var cumulative float64
+	for _, v := range valid {
+		cumulative += v.weight
+		if cumulative >= totalWeight/2 {
+			m.TimeWeightedMedian = v.value
+			break
+		}
+	}
+
+	m.TimeWeighted = true
+	return true
+}
+
 type Correlation struct {
 	MeasurementA string  `json:"measurement_a"`
 	MeasurementB string  `json:"measurement_b"`
@@ -0,0 +1,36 @@
+package fit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFinalizeTimeWeightedZero(t *testing.T) {
+	start := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMeasurement("power", "watts", 0xFFFF)
+	mmap := map[string]*Measurement{"power": m}
+	timestamps := make([]time.Time, 0, 3)
+	for i := 0; i < 3; i++ {
+		addValue(mmap, "power", 0)
+		timestamps = append(timestamps, start.Add(time.Duration(i)*time.Second))
+	}
+
+	m, ok := m.Finalize()
+	if !ok {
+		t.Fatal("finalize: not valid")
+	}
+	if !m.FinalizeTimeWeighted(timestamps, time.Minute) || !m.TimeWeighted {
+		t.Fatal("finalize time weighted: not calculated")
+	}
+	if m.TimeWeightedMean != 0 || m.TimeWeightedMedian != 0 {
+		t.Errorf("got mean %v, median %v, expected zero", m.TimeWeightedMean, m.TimeWeightedMedian)
+	}
+
+	unset := NewMeasurement("power", "watts", 0xFFFF)
+	mmap = map[string]*Measurement{"power": unset}
+	addValue(mmap, "power", 0xFFFF)
+	addValue(mmap, "power", 0xFFFF)
+	if unset.FinalizeTimeWeighted(timestamps[:2], time.Minute) || unset.TimeWeighted {
+		t.Error("finalize time weighted: calculated without values")
+	}
+}
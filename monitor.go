@@ -0,0 +1,228 @@
+package fit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/subtlepseudonym/fit-go"
+)
+
+// Monitoring values are counters accumulated per activity type by the
+// device and reset daily. Line protocol contains the accumulated values,
+// while summaries are calculated from the increase over each interval.
+// Distance is shared with DefaultSportMeasurements.
+var DefaultMonitoringMeasurements = []MeasurementDefinition{
+	{Name: "active_time", Unit: "millisecond", Unset: 0xFFFFFFFF, Types: []string{TypeMonitoring}},
+	{Name: "calories", Unit: "kilocalorie", Unset: 0xFFFF, Types: []string{TypeMonitoring}},
+	{Name: "steps", Unit: "steps", Unset: 0xFFFFFFFF, Types: []string{TypeMonitoring}},
+}
+
+type monitorKey struct {
+	activityType fit.ActivityType
+	measurement  string
+}
+
+// MonitoringDay contains the increase of each monitoring counter, summed
+// across activity types, over a single local calendar day
+type MonitoringDay struct {
+	Date   string             `json:"date"` // YYYY-MM-DD
+	Totals map[string]float64 `json:"totals"`
+}
+
+// MonitoringAccumulator tracks accumulated monitoring counters in order to
+// expand compressed values and calculate totals by day
+type MonitoringAccumulator struct {
+	last map[monitorKey]uint32
+	days map[string]*MonitoringDay
+
+	offset time.Duration // local time offset from UTC
+	day    string        // local date of the last timestamped message
+}
+
+func NewMonitoringAccumulator() *MonitoringAccumulator {
+	return &MonitoringAccumulator{
+		last: make(map[monitorKey]uint32),
+		days: make(map[string]*MonitoringDay),
+	}
+}
+
+// Totals returns the increase of each counter across all activity types
+// and days, including counters that were reset during the file
+func (a *MonitoringAccumulator) Totals() map[string]float64 {
+	totals := make(map[string]float64)
+	for _, day := range a.days {
+		for name, total := range day.Totals {
+			totals[name] += total
+		}
+	}
+
+	return totals
+}
+
+// Days returns the counter totals for each day, ordered by date
+func (a *MonitoringAccumulator) Days() []*MonitoringDay {
+	days := make([]*MonitoringDay, 0, len(a.days))
+	for _, day := range a.days {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Date < days[j].Date
+	})
+
+	return days
+}
+
+// setTimestamp sets the day that subsequent counter increases are added to.
+// Messages with compressed timestamps belong to the day of the previous
+// timestamped message.
+func (a *MonitoringAccumulator) setTimestamp(timestamp time.Time) {
+	if fit.IsBaseTime(timestamp) {
+		return
+	}
+	a.day = timestamp.Add(a.offset).UTC().Format("2006-01-02")
+}
+
+// accumulate records the accumulated value and returns its increase since
+// the previous value. Counters start from zero when first seen or reset.
+func (a *MonitoringAccumulator) accumulate(key monitorKey, value uint32) float64 {
+	delta := float64(value)
+	if last, ok := a.last[key]; ok && value >= last {
+		delta = float64(value - last)
+	}
+	a.last[key] = value
+
+	day, ok := a.days[a.day]
+	if !ok {
+		day = &MonitoringDay{Date: a.day, Totals: make(map[string]float64)}
+		a.days[a.day] = day
+	}
+	day.Totals[key.measurement] += delta
+
+	return delta
+}
+
+// monitoringOffset returns the local time offset recorded in the monitoring
+// info message, or zero if it is not set
+func monitoringOffset(info *fit.MonitoringInfoMsg) time.Duration {
+	if info == nil || fit.IsBaseTime(info.Timestamp) || fit.IsBaseTime(info.LocalTimestamp) {
+		return 0
+	}
+
+	// local timestamps are encoded as though they were UTC
+	return info.LocalTimestamp.Sub(info.Timestamp)
+}
+
+// expand16 reconstructs a full accumulated value from its lower 16 bits
+// using the previous value of the same counter
+func (a *MonitoringAccumulator) expand16(key monitorKey, value uint16) (uint32, bool) {
+	last, ok := a.last[key]
+	if !ok {
+		return 0, false
+	}
+
+	expanded := last&^0xFFFF | uint32(value)
+	if expanded < last {
+		expanded += 0x10000
+	}
+
+	return expanded, true
+}
+
+// counter returns the accumulated value from the full or compressed 16-bit
+// field, whichever is set
+func (a *MonitoringAccumulator) counter(key monitorKey, value uint32, value16 uint16) (uint32, bool) {
+	if value != 0xFFFFFFFF {
+		return value, true
+	}
+	if value16 != 0xFFFF {
+		return a.expand16(key, value16)
+	}
+
+	return 0, false
+}
+
+// MonitoringActivityType returns the activity type name used to tag
+// monitoring values
+func MonitoringActivityType(msg *fit.MonitoringMsg) string {
+	if msg.ActivityType == fit.ActivityTypeInvalid {
+		return TypeUnknown
+	}
+	return strings.ToLower(msg.ActivityType.String())
+}
+
+// ReadMonitoring adds the accumulated value of each counter in the message
+func ReadMonitoring(accumulator *MonitoringAccumulator, msg *fit.MonitoringMsg, add AddFunc) (*MonitoringAccumulator, error) {
+	return readMonitoring(accumulator, msg, add, false)
+}
+
+// readMonitoring adds each counter's accumulated value, or its increase
+// since the previous message if deltas is set
+func readMonitoring(accumulator *MonitoringAccumulator, msg *fit.MonitoringMsg, add AddFunc, deltas bool) (*MonitoringAccumulator, error) {
+	accumulator.setTimestamp(msg.Timestamp)
+	if msg.ActivityType == fit.ActivityTypeInvalid {
+		return accumulator, nil
+	}
+
+	addCounter := func(name string, value uint32, value16 uint16) {
+		key := monitorKey{msg.ActivityType, name}
+		v, ok := accumulator.counter(key, value, value16)
+		if !ok {
+			return
+		}
+		delta := accumulator.accumulate(key, v)
+		if deltas {
+			add(name, delta)
+		} else {
+			add(name, v)
+		}
+	}
+
+	addCounter("active_time", msg.ActiveTime, msg.ActiveTime16)
+	addCounter("distance", msg.Distance, msg.Distance16)
+	if msg.Calories != 0xFFFF {
+		addCounter("calories", uint32(msg.Calories), 0xFFFF)
+	}
+
+	// cycles are only steps for on-foot activity types
+	switch msg.ActivityType {
+	case fit.ActivityTypeWalking, fit.ActivityTypeRunning:
+		addCounter("steps", msg.Cycles, msg.Cycles16)
+	}
+
+	return accumulator, nil
+}
+
+// monitorings returns the monitoring messages from any monitoring file type
+func monitorings(data *fit.File) ([]*fit.MonitoringMsg, error) {
+	msgs, _, err := monitoringData(data)
+	return msgs, err
+}
+
+// monitoringData returns the monitoring messages and info message from any
+// monitoring file type
+func monitoringData(data *fit.File) ([]*fit.MonitoringMsg, *fit.MonitoringInfoMsg, error) {
+	switch data.Type() {
+	case fit.FileTypeMonitoringA:
+		monitor, err := data.MonitoringA()
+		if err != nil {
+			return nil, nil, fmt.Errorf("monitoring a: %w", err)
+		}
+		return monitor.Monitorings, monitor.MonitoringInfo, nil
+	case fit.FileTypeMonitoringB:
+		monitor, err := data.MonitoringB()
+		if err != nil {
+			return nil, nil, fmt.Errorf("monitoring b: %w", err)
+		}
+		return monitor.Monitorings, monitor.MonitoringInfo, nil
+	case fit.FileTypeMonitoringDaily:
+		monitor, err := data.MonitoringDaily()
+		if err != nil {
+			return nil, nil, fmt.Errorf("monitoring daily: %w", err)
+		}
+		return monitor.Monitorings, monitor.MonitoringInfo, nil
+	}
+
+	return nil, nil, fmt.Errorf("not a monitoring file type: %d", data.Type())
+}
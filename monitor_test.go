@@ -0,0 +1,60 @@
+package fit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/subtlepseudonym/fit-go"
+)
+
+func TestMonitoringAccumulatorDays(t *testing.T) {
+	msg := func(timestamp time.Time, steps uint32) *fit.MonitoringMsg {
+		m := fit.NewMonitoringMsg()
+		m.ActivityType = fit.ActivityTypeWalking
+		m.Cycles = steps
+		if !timestamp.IsZero() {
+			m.Timestamp = timestamp
+		}
+		return m
+	}
+
+	day := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+	msgs := []*fit.MonitoringMsg{
+		msg(day.Add(10*time.Hour), 100),
+		msg(day.Add(11*time.Hour), 250),
+		msg(day.Add(24*time.Hour+15*time.Minute), 20), // reset at midnight
+		msg(time.Time{}, 50),                          // compressed timestamp
+	}
+
+	var deltas []interface{}
+	acc := NewMonitoringAccumulator()
+	for _, m := range msgs {
+		var err error
+		acc, err = readMonitoring(acc, m, func(key string, value interface{}) {
+			if key == "steps" {
+				deltas = append(deltas, value)
+			}
+		}, true)
+		if err != nil {
+			t.Fatalf("read monitoring: %s", err)
+		}
+	}
+
+	expectedDeltas := []interface{}{100.0, 150.0, 20.0, 30.0}
+	if !reflect.DeepEqual(deltas, expectedDeltas) {
+		t.Errorf("deltas: got %v, expected %v", deltas, expectedDeltas)
+	}
+
+	expectedDays := []*MonitoringDay{
+		{Date: "2023-08-01", Totals: map[string]float64{"steps": 250}},
+		{Date: "2023-08-02", Totals: map[string]float64{"steps": 50}},
+	}
+	if days := acc.Days(); !reflect.DeepEqual(days, expectedDays) {
+		t.Errorf("days: got %+v, expected %+v", days, expectedDays)
+	}
+
+	if totals := acc.Totals(); totals["steps"] != 300 {
+		t.Errorf("total steps: got %v, expected 300", totals["steps"])
+	}
+}
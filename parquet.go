@@ -0,0 +1,115 @@
+package fit
+
+import (
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/subtlepseudonym/fit-go"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// ParquetSchema returns the parquet column metadata for record datasets.
+// Every registered measurement is included so that files in all partitions
+// share a schema.
+func ParquetSchema() []string {
+	return parquetSchema(DefaultRegistry)
+}
+
+// parquetSchema returns the parquet column metadata for record datasets
+// containing every measurement in the registry
+func parquetSchema(registry *MeasurementRegistry) []string {
+	schema := []string{
+		"name=timestamp, type=INT64, convertedtype=TIMESTAMP_MILLIS",
+		"name=file_checksum, type=BYTE_ARRAY, convertedtype=UTF8",
+		"name=type, type=BYTE_ARRAY, convertedtype=UTF8",
+	}
+	for _, name := range registry.AllNames() {
+		schema = append(schema, fmt.Sprintf("name=%s, type=DOUBLE, repetitiontype=OPTIONAL", name))
+	}
+
+	return schema
+}
+
+// ParquetPartition returns the hive-style partition directory for the
+// activity, partitioned by activity type and month
+func ParquetPartition(data *fit.File) (string, error) {
+	records, err := activityRecords(data)
+	if err != nil {
+		return "", err
+	}
+
+	activityType, err := Type(data)
+	if err != nil {
+		return "", fmt.Errorf("type: %w", err)
+	}
+
+	month := records[0].Timestamp.UTC().Format("2006-01")
+	return path.Join("type="+activityType.Type, "month="+month), nil
+}
+
+// WriteParquet writes one row per activity record using ParquetSchema.
+// Unset values are written as null. The checksum identifies the source file
+// and, as the file's hex encoded SHA-256, joins to checksums in the etl
+// import file table rather than to activity IDs. Options configure the
+// accumulator used to read records.
+func WriteParquet(out io.Writer, data *fit.File, checksum string, options ...AccumulatorOption) error {
+	records, err := activityRecords(data)
+	if err != nil {
+		return err
+	}
+
+	activityType, err := Type(data)
+	if err != nil {
+		return fmt.Errorf("type: %w", err)
+	}
+
+	acc := NewAccumulator(options...)
+	names := acc.registry.AllNames()
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i + 3
+	}
+
+	pw, err := writer.NewCSVWriterFromWriter(parquetSchema(acc.registry), out, 1)
+	if err != nil {
+		return fmt.Errorf("parquet writer: %w", err)
+	}
+
+	// rows are buffered by the writer until flushed, so each record
+	// requires a new row
+	var row []interface{}
+	add := func(key string, value interface{}) {
+		i, ok := index[key]
+		if !ok {
+			return
+		}
+		if v, ok := ToFloat64(value); ok && !acc.registry.IsUnset(key, v) {
+			row[i] = v
+		}
+	}
+
+	for _, record := range records {
+		row = make([]interface{}, len(names)+3)
+		row[0] = record.Timestamp.UnixMilli()
+		row[1] = checksum
+		row[2] = activityType.Type
+
+		acc, err = ReadRecord(acc, record, add)
+		if err != nil {
+			return fmt.Errorf("read record: %w", err)
+		}
+
+		err = pw.Write(row)
+		if err != nil {
+			return fmt.Errorf("write row: %w", err)
+		}
+	}
+
+	err = pw.WriteStop()
+	if err != nil {
+		return fmt.Errorf("write stop: %w", err)
+	}
+
+	return nil
+}
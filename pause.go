@@ -0,0 +1,181 @@
+package fit
+
+import (
+	"sort"
+	"time"
+
+	"github.com/subtlepseudonym/fit-go"
+)
+
+const (
+	PauseTimer   = "timer"   // device timer was stopped
+	PauseGap     = "gap"     // no records were written
+	PauseStopped = "stopped" // speed was below the moving threshold
+)
+
+// MinPauseDuration is the shortest period below the moving threshold that
+// is reported as a pause. Shorter stops are still excluded from moving time.
+const MinPauseDuration = 10 * time.Second
+
+// Pause is an interval within an activity during which the athlete was not
+// moving or the device was not recording
+type Pause struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Reason    string    `json:"reason"`
+}
+
+func (p *Pause) Duration() time.Duration {
+	return p.EndTime.Sub(p.StartTime)
+}
+
+func (p *Pause) overlaps(start, end time.Time) bool {
+	return start.Before(p.EndTime) && end.After(p.StartTime)
+}
+
+// calculatePauses detects pauses from timer events, gaps between records,
+// and records below the moving threshold, then sets the activity's elapsed,
+// timer, and moving times
+func (a *Activity) calculatePauses(records []*fit.RecordMsg, events []*fit.EventMsg) {
+	a.ElapsedTime = a.EndTime.Sub(a.StartTime).Seconds()
+	a.TimerTime = a.ElapsedTime
+	a.Pauses = timerPauses(events, a.StartTime, a.EndTime)
+	for _, pause := range a.Pauses {
+		a.TimerTime -= pause.Duration().Seconds()
+	}
+	timer := len(a.Pauses)
+
+	var stopped *Pause
+	endStopped := func() {
+		if stopped != nil && stopped.Duration() >= MinPauseDuration {
+			a.Pauses = append(a.Pauses, stopped)
+		}
+		stopped = nil
+	}
+
+	for i := 0; i < len(records)-1; i++ {
+		start, end := records[i].Timestamp, records[i+1].Timestamp
+		interval := end.Sub(start)
+		if interval <= 0 {
+			continue
+		}
+
+		var paused bool
+		for _, pause := range a.Pauses[:timer] {
+			if pause.overlaps(start, end) {
+				paused = true
+				break
+			}
+		}
+		if paused {
+			endStopped()
+			continue
+		}
+
+		if interval > RecordMaxGap {
+			endStopped()
+			a.Pauses = append(a.Pauses, &Pause{start, end, PauseGap})
+			continue
+		}
+
+		// records without speed are assumed to be moving
+		speed := float64(records[i].EnhancedSpeed)
+		if !IsUnset("speed", speed) && speed <= DefaultMovingThreshold {
+			if stopped == nil {
+				stopped = &Pause{StartTime: start, Reason: PauseStopped}
+			}
+			stopped.EndTime = end
+			continue
+		}
+
+		endStopped()
+		a.MovingTime += interval.Seconds()
+	}
+	endStopped()
+
+	sort.SliceStable(a.Pauses, func(i, j int) bool {
+		return a.Pauses[i].StartTime.Before(a.Pauses[j].StartTime)
+	})
+}
+
+// timerPauses returns the intervals between timer stop and start events,
+// limited to the activity time window
+func timerPauses(events []*fit.EventMsg, start, end time.Time) []*Pause {
+	timerEvents := make([]*fit.EventMsg, 0, len(events))
+	for _, event := range events {
+		if event != nil && event.Event == fit.EventTimer {
+			timerEvents = append(timerEvents, event)
+		}
+	}
+	sort.SliceStable(timerEvents, func(i, j int) bool {
+		return timerEvents[i].Timestamp.Before(timerEvents[j].Timestamp)
+	})
+
+	var pauses []*Pause
+	var stoppedAt time.Time
+	for _, event := range timerEvents {
+		switch event.EventType {
+		case fit.EventTypeStop, fit.EventTypeStopAll, fit.EventTypeStopDisable, fit.EventTypeStopDisableAll:
+			if stoppedAt.IsZero() {
+				stoppedAt = event.Timestamp
+			}
+		case fit.EventTypeStart:
+			if stoppedAt.IsZero() {
+				continue
+			}
+
+			pause := &Pause{stoppedAt, event.Timestamp, PauseTimer}
+			stoppedAt = time.Time{}
+			if pause.StartTime.Before(start) {
+				pause.StartTime = start
+			}
+			if pause.EndTime.After(end) {
+				pause.EndTime = end
+			}
+			if pause.EndTime.After(pause.StartTime) {
+				pauses = append(pauses, pause)
+			}
+		}
+	}
+
+	return pauses
+}
+
+// splitAtPauses splits records into spans separated by detected pauses,
+// dropping records recorded during a pause
+func splitAtPauses(records []*fit.RecordMsg, events []*fit.EventMsg) [][]*fit.RecordMsg {
+	if len(records) == 0 {
+		return nil
+	}
+
+	activity := &Activity{
+		StartTime: records[0].Timestamp,
+		EndTime:   records[len(records)-1].Timestamp,
+	}
+	activity.calculatePauses(records, events)
+
+	var spans [][]*fit.RecordMsg
+	var span []*fit.RecordMsg
+	pauses := activity.Pauses
+	for _, record := range records {
+		var paused bool
+		for len(pauses) > 0 && !record.Timestamp.Before(pauses[0].EndTime) {
+			pauses = pauses[1:]
+			paused = true
+		}
+		if len(pauses) > 0 && record.Timestamp.After(pauses[0].StartTime) {
+			continue
+		}
+
+		if paused && len(span) > 0 {
+			spans = append(spans, span)
+			span = nil
+		}
+		span = append(span, record)
+	}
+	if len(span) > 0 {
+		spans = append(spans, span)
+	}
+
+	return spans
+}
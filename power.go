@@ -0,0 +1,122 @@
+package fit
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// powerRollingWindow is the rolling average window used to calculate
+	// normalized power
+	powerRollingWindow = 30
+
+	// powerMaxGap is the longest gap between power samples, in seconds,
+	// across which the previous value is held; longer gaps are treated as
+	// zero power
+	powerMaxGap = 10
+)
+
+// Power contains derived cycling power metrics
+type Power struct {
+	Average                  float64 `json:"average"`
+	NormalizedPower          float64 `json:"normalized_power"`
+	VariabilityIndex         float64 `json:"variability_index"`
+	FunctionalThresholdPower float64 `json:"functional_threshold_power,omitempty"`
+	IntensityFactor          float64 `json:"intensity_factor,omitempty"`
+	TrainingStressScore      float64 `json:"training_stress_score,omitempty"`
+
+	duration float64 `json:"-"`
+}
+
+type sample struct {
+	timestamp time.Time
+	value     float64
+}
+
+// resample converts samples to a one second series, holding the previous
+// value across gaps no longer than maxGap seconds. Samples with a timestamp
+// earlier than the preceding sample are dropped.
+func resample(samples []sample, maxGap int) []float64 {
+	ordered := make([]sample, 0, len(samples))
+	for _, s := range samples {
+		if len(ordered) > 0 && s.timestamp.Before(ordered[len(ordered)-1].timestamp) {
+			continue
+		}
+		ordered = append(ordered, s)
+	}
+	if len(ordered) == 0 {
+		return nil
+	}
+
+	start := ordered[0].timestamp
+	length := int(ordered[len(ordered)-1].timestamp.Sub(start)/time.Second) + 1
+	if length <= 0 {
+		return nil
+	}
+	series := make([]float64, length)
+
+	for i, s := range ordered {
+		idx := int(s.timestamp.Sub(start) / time.Second)
+		if idx < 0 || idx >= length {
+			continue
+		}
+		end := length
+		if i+1 < len(ordered) {
+			end = int(ordered[i+1].timestamp.Sub(start) / time.Second)
+		}
+		if end-idx > maxGap {
+			end = idx + 1
+		}
+		for j := idx; j < end && j < length; j++ {
+			series[j] = s.value
+		}
+	}
+
+	return series
+}
+
+// calculatePower calculates average power, normalized power, and
+// variability index from power samples
+func calculatePower(samples []sample) *Power {
+	series := resample(samples, powerMaxGap)
+	if len(series) < powerRollingWindow {
+		return nil
+	}
+
+	var sum, rolling, fourth float64
+	var count int
+	for i, v := range series {
+		sum += v
+		rolling += v
+		if i >= powerRollingWindow {
+			rolling -= series[i-powerRollingWindow]
+		}
+		if i >= powerRollingWindow-1 {
+			fourth += math.Pow(rolling/powerRollingWindow, 4)
+			count++
+		}
+	}
+
+	power := &Power{
+		Average:         sum / float64(len(series)),
+		NormalizedPower: math.Pow(fourth/float64(count), 0.25),
+		duration:        float64(len(series)),
+	}
+	if power.Average > 0 {
+		power.VariabilityIndex = power.NormalizedPower / power.Average
+	}
+
+	return power
+}
+
+// CalculateTrainingLoad calculates intensity factor and training stress
+// score relative to the provided functional threshold power
+func (p *Power) CalculateTrainingLoad(ftp float64) {
+	if ftp <= 0 {
+		return
+	}
+
+	p.FunctionalThresholdPower = ftp
+	p.IntensityFactor = p.NormalizedPower / ftp
+	p.TrainingStressScore = p.duration * p.NormalizedPower * p.IntensityFactor / (ftp * 3600) * 100
+}
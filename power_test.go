@@ -0,0 +1,58 @@
+package fit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResample(t *testing.T) {
+	start := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int, value float64) sample {
+		return sample{start.Add(time.Duration(seconds) * time.Second), value}
+	}
+
+	tests := []struct {
+		name     string
+		samples  []sample
+		expected []float64
+	}{
+		{
+			name:     "empty",
+			samples:  nil,
+			expected: nil,
+		},
+		{
+			name:     "hold across short gap",
+			samples:  []sample{at(0, 1), at(3, 2)},
+			expected: []float64{1, 1, 1, 2},
+		},
+		{
+			name:     "zero across long gap",
+			samples:  []sample{at(0, 1), at(4, 2)},
+			expected: []float64{1, 0, 0, 0, 2},
+		},
+		{
+			name:     "backwards last sample",
+			samples:  []sample{at(0, 1), at(2, 2), at(-5, 3)},
+			expected: []float64{1, 1, 2},
+		},
+		{
+			name:     "backwards middle sample",
+			samples:  []sample{at(5, 1), at(0, 2), at(6, 3)},
+			expected: []float64{1, 3},
+		},
+		{
+			name:     "repeated timestamp",
+			samples:  []sample{at(0, 1), at(0, 2), at(1, 3)},
+			expected: []float64{2, 3},
+		},
+	}
+
+	for _, test := range tests {
+		series := resample(test.samples, 3)
+		if !reflect.DeepEqual(series, test.expected) {
+			t.Errorf("%s: got %v, expected %v", test.name, series, test.expected)
+		}
+	}
+}
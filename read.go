@@ -3,75 +3,235 @@ package fit
 import (
 	"fmt"
 	"math"
+	"time"
 
 	"github.com/jftuga/geodist"
 	"github.com/subtlepseudonym/fit-go"
 )
 
-type measure struct {
-	Unit  string
-	Unset uint
+// DefaultRegistry contains the default measurements and any measurements
+// registered by the caller. It is used for summarizing and writing records
+// unless another registry is provided with WithRegistry.
+var DefaultRegistry = NewDefaultMeasurementRegistry()
+
+// DefaultMeasurements apply to all activity types
+var DefaultMeasurements = []MeasurementDefinition{
+	{Name: "altitude", Unit: "meter", Unset: 0xFFFFFFFF, Extract: func(r *fit.RecordMsg) interface{} { return r.GetEnhancedAltitudeScaled() }},
+	{Name: "ascent", Unit: "meter", Unset: 0xFFFFFFFF},
+	{Name: "descent", Unit: "meter", Unset: 0xFFFFFFFF},
+	{Name: "heart_rate", Unit: "1 / minute", Unset: 0xFF, Extract: func(r *fit.RecordMsg) interface{} { return r.HeartRate }},
+	{Name: "smoothed_altitude", Unit: "meter", Unset: 0xFFFFFFFF},
+	{Name: "temperature", Unit: "degrees Celsius", Unset: 0x7F, Extract: func(r *fit.RecordMsg) interface{} { return r.Temperature }},
 }
 
-var DefaultMeasurements = map[string]measure{
-	"altitude":    {"meter", 0xFFFFFFFF},
-	"heart_rate":  {"1 / minute", 0xFF},
-	"temperature": {"degrees Celsius", 0x7F},
+var sportExcludeTypes = []string{TypeMonitoring, TypeTracking}
+
+// DefaultSportMeasurements apply to all activity types other than
+// monitoring and tracking
+var DefaultSportMeasurements = []MeasurementDefinition{
+	// distance is also read from monitoring messages
+	{Name: "distance", Unit: "centimeter", Unset: 0xFFFFFFFF, ExcludeTypes: []string{TypeTracking}, Extract: func(r *fit.RecordMsg) interface{} { return r.Distance }},
+	{Name: "grade", Unit: "percent", Unset: 0xFFFFFFFF, ExcludeTypes: sportExcludeTypes},
+	{Name: "latitude", Unit: "degrees", Unset: 0xFF, ExcludeTypes: sportExcludeTypes, Extract: func(r *fit.RecordMsg) interface{} { return r.PositionLat.Degrees() }},
+	{Name: "longitude", Unit: "degrees", Unset: 0xFF, ExcludeTypes: sportExcludeTypes, Extract: func(r *fit.RecordMsg) interface{} { return r.PositionLong.Degrees() }},
+	{Name: "moving_speed", Unit: "millimeter / second", Unset: 0xFFFFFFFF, ExcludeTypes: sportExcludeTypes},
+	{Name: "speed", Unit: "millimeter / second", Unset: 0xFFFFFFFF, ExcludeTypes: sportExcludeTypes, Extract: func(r *fit.RecordMsg) interface{} { return r.EnhancedSpeed }},
+	{Name: "vicenty_distance", Unit: "centimeter", Unset: 0xFFFFFFFF, ExcludeTypes: sportExcludeTypes},
 }
 
-var DefaultSportMeasurements = map[string]measure{
-	"distance":         {"centimeter", 0xFFFFFFFF},
-	"latitude":         {"degrees", 0xFF},
-	"longitude":        {"degrees", 0xFF},
-	"moving_speed":     {"millimeter / second", 0xFFFFFFFF},
-	"speed":            {"millimeter / second", 0xFFFFFFFF},
-	"vicenty_distance": {"centimeter", 0xFFFFFFFF},
+var DefaultCyclingMeasurements = []MeasurementDefinition{
+	{Name: "cadence", Unit: "1 / minute", Unset: 0xFF, Types: []string{TypeCycling}, Extract: func(r *fit.RecordMsg) interface{} { return r.Cadence }},
+	{Name: "power", Unit: "watt", Unset: 0xFFFF, Types: []string{TypeCycling}, Extract: func(r *fit.RecordMsg) interface{} { return r.Power }},
 }
 
-var DefaultCyclingMeasurements = map[string]measure{
-	"cadence": {"1 / minute", 0xFF},
+// NewDefaultMeasurementRegistry returns a registry containing the default
+// measurements
+func NewDefaultMeasurementRegistry() *MeasurementRegistry {
+	registry := NewMeasurementRegistry()
+	defaults := [][]MeasurementDefinition{
+		DefaultMeasurements,
+		DefaultSportMeasurements,
+		DefaultCyclingMeasurements,
+		DefaultMonitoringMeasurements,
+	}
+	for _, definitions := range defaults {
+		for _, definition := range definitions {
+			err := registry.Register(definition)
+			if err != nil {
+				panic(fmt.Sprintf("register default measurement: %s", err))
+			}
+		}
+	}
+
+	return registry
 }
 
+// RecordMaxGap is the longest interval between records that is considered
+// continuous; longer intervals are assumed to be pauses
+const RecordMaxGap = 30 * time.Second
+
 const DefaultMovingThreshold = 112 // 112 mm/s ~= 0.25 mph
 
+// DefaultAltitudeHysteresis is the altitude change in meters required
+// before smoothed altitude follows recorded altitude
+const DefaultAltitudeHysteresis = 2.0
+
+// GradeMinDistance is the minimum distance in meters over which grade is
+// calculated; grade is held between calculations
+const GradeMinDistance = 10.0
+
+// MaxGrade is the steepest grade in percent that is reported; steeper
+// grades are clamped, as over GradeMinDistance they are most often caused
+// by altitude errors rather than terrain
+const MaxGrade = 50.0
+
 // Accumulator is used to calculate generated measurements that require
 // multi-record context
 type Accumulator struct {
+	Hysteresis float64
+
+	registry *MeasurementRegistry
+
 	index         int
 	startPosition *geodist.Coord
+
+	altitudeSet bool
+	altitude    float64
+	ascent      float64
+	descent     float64
+
+	gradeSet      bool
+	gradeDistance float64
+	gradeAltitude float64
+	grade         float64
 }
 
-type AddFunc func(key string, value interface{})
+// AccumulatorOption configures a new Accumulator
+type AccumulatorOption func(*Accumulator)
 
-func IsUnset(key string, value float64) bool {
-	if math.IsNaN(value) {
-		return true
+// WithHysteresis sets the altitude change in meters required before
+// smoothed altitude follows recorded altitude; larger values reject more
+// barometric noise at the cost of undercounting ascent and descent on
+// rolling terrain
+func WithHysteresis(hysteresis float64) AccumulatorOption {
+	return func(a *Accumulator) {
+		a.Hysteresis = hysteresis
 	}
+}
 
-	if m, ok := DefaultMeasurements[key]; ok {
-		return value >= float64(m.Unset)
+// WithRegistry sets the registry of measurements read from records in place
+// of DefaultRegistry
+func WithRegistry(registry *MeasurementRegistry) AccumulatorOption {
+	return func(a *Accumulator) {
+		a.registry = registry
 	}
-	if m, ok := DefaultSportMeasurements[key]; ok {
-		return value >= float64(m.Unset)
+}
+
+func NewAccumulator(options ...AccumulatorOption) *Accumulator {
+	acc := &Accumulator{
+		Hysteresis: DefaultAltitudeHysteresis,
+		registry:   DefaultRegistry,
+		grade:      math.NaN(),
 	}
-	if m, ok := DefaultCyclingMeasurements[key]; ok {
-		return value >= float64(m.Unset)
+	for _, option := range options {
+		option(acc)
 	}
+	return acc
+}
 
-	return true
+// Registry returns the registry of measurements read from records
+func (a *Accumulator) Registry() *MeasurementRegistry {
+	return a.registry
 }
 
+// Totals returns cumulative values calculated from the records read, or nil
+// if no altitude was recorded
+func (a *Accumulator) Totals() map[string]float64 {
+	if !a.altitudeSet {
+		return nil
+	}
+
+	return map[string]float64{
+		"ascent":  a.ascent,
+		"descent": a.descent,
+	}
+}
+
+// readElevation adds smoothed altitude, cumulative ascent and descent, and
+// grade. Smoothed altitude only follows recorded altitude once it has
+// changed by more than the hysteresis, so noise within that band is not
+// counted as ascent or descent.
+func (a *Accumulator) readElevation(altitude, distance float64, add AddFunc) {
+	if !math.IsNaN(altitude) {
+		switch {
+		case !a.altitudeSet:
+			a.altitude = altitude
+			a.altitudeSet = true
+		case altitude > a.altitude+a.Hysteresis:
+			a.ascent += altitude - a.Hysteresis - a.altitude
+			a.altitude = altitude - a.Hysteresis
+		case altitude < a.altitude-a.Hysteresis:
+			a.descent += a.altitude - (altitude + a.Hysteresis)
+			a.altitude = altitude + a.Hysteresis
+		}
+	}
+
+	if !a.altitudeSet {
+		add("smoothed_altitude", math.NaN())
+		add("ascent", math.NaN())
+		add("descent", math.NaN())
+		add("grade", math.NaN())
+		return
+	}
+
+	add("smoothed_altitude", a.altitude)
+	add("ascent", a.ascent)
+	add("descent", a.descent)
+
+	if a.registry.IsUnset("distance", distance) {
+		add("grade", math.NaN())
+		return
+	}
+
+	// distance is recorded in centimeters
+	if !a.gradeSet {
+		a.gradeDistance = distance
+		a.gradeAltitude = a.altitude
+		a.gradeSet = true
+	} else if meters := (distance - a.gradeDistance) / 100; meters >= GradeMinDistance {
+		a.grade = (a.altitude - a.gradeAltitude) / meters * 100
+		a.grade = math.Max(-MaxGrade, math.Min(MaxGrade, a.grade))
+		a.gradeDistance = distance
+		a.gradeAltitude = a.altitude
+	}
+	add("grade", a.grade)
+}
+
+type AddFunc func(key string, value interface{})
+
+// IsUnset returns whether the value is the measurement's unset value in
+// DefaultRegistry
+func IsUnset(key string, value float64) bool {
+	return DefaultRegistry.IsUnset(key, value)
+}
+
+// ReadRecord reads the record using the accumulator's registry
 func ReadRecord(accumulator *Accumulator, record *fit.RecordMsg, add AddFunc) (*Accumulator, error) {
+	return accumulator.registry.ReadRecord(accumulator, record, add)
+}
+
+// ReadRecord adds the record's value for each registered measurement with an
+// extractor, followed by measurements derived from multiple records
+func (r *MeasurementRegistry) ReadRecord(accumulator *Accumulator, record *fit.RecordMsg, add AddFunc) (*Accumulator, error) {
 	accumulator.index += 1
 
-	add("altitude", record.GetEnhancedAltitudeScaled())
-	add("cadence", record.Cadence)
-	add("distance", record.Distance)
-	add("heart_rate", record.HeartRate)
-	add("latitude", record.PositionLat.Degrees())
-	add("longitude", record.PositionLong.Degrees())
-	add("speed", record.EnhancedSpeed)
-	add("temperature", record.Temperature)
+	for _, name := range r.names {
+		if extract := r.definitions[name].Extract; extract != nil {
+			add(name, extract(record))
+		}
+	}
+
+	accumulator.readElevation(record.GetEnhancedAltitudeScaled(), float64(record.Distance), add)
 
 	if record.EnhancedSpeed > DefaultMovingThreshold {
 		add("moving_speed", float64(record.EnhancedSpeed))
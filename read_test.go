@@ -0,0 +1,83 @@
+package fit
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAccumulatorHysteresis(t *testing.T) {
+	tests := []struct {
+		options []AccumulatorOption
+		ascent  float64
+		descent float64
+	}{
+		{options: nil, ascent: 3, descent: 0},
+		{options: []AccumulatorOption{WithHysteresis(0)}, ascent: 5, descent: 3},
+		{options: []AccumulatorOption{WithHysteresis(10)}, ascent: 0, descent: 0},
+	}
+
+	for _, test := range tests {
+		acc := NewAccumulator(test.options...)
+		for _, altitude := range []float64{100, 101, 105, 102} {
+			acc.readElevation(altitude, 0, func(string, interface{}) {})
+		}
+
+		totals := acc.Totals()
+		if totals["ascent"] != test.ascent || totals["descent"] != test.descent {
+			t.Errorf("hysteresis %v: got ascent %v, descent %v, expected %v, %v", acc.Hysteresis, totals["ascent"], totals["descent"], test.ascent, test.descent)
+		}
+	}
+}
+
+func TestGradeNoisyAltitude(t *testing.T) {
+	tests := []struct {
+		name  string
+		noise []float64
+		spike float64
+	}{
+		{name: "clean", noise: []float64{0}},
+		{name: "noisy", noise: []float64{0, 4, -3, 6, -5, 2}, spike: 90},
+	}
+
+	for _, test := range tests {
+		var grade float64
+		acc := NewAccumulator()
+		for i := 0; i < 600; i++ {
+			// 5% climb at 3 meters per second with barometric noise and an
+			// altitude spike, as seen when a barometer settles
+			meters := float64(i) * 3
+			altitude := 100 + meters*0.05 + test.noise[i%len(test.noise)]
+			if i >= 3 && i < 7 {
+				altitude += test.spike
+			}
+
+			acc.readElevation(altitude, meters*100, func(key string, value interface{}) {
+				if key == "grade" {
+					grade = value.(float64)
+				}
+			})
+			if math.IsNaN(grade) {
+				continue
+			}
+			if grade > MaxGrade || grade < -MaxGrade {
+				t.Fatalf("%s: record %d: grade %v outside of +/-%v", test.name, i, grade, MaxGrade)
+			}
+		}
+
+		if test.spike == 0 && math.Abs(grade-5) > 1 {
+			t.Errorf("%s: got grade %v, expected 5", test.name, grade)
+		}
+	}
+}
+
+func TestNegativeMeasurementBounds(t *testing.T) {
+	m := NewMeasurement("grade", "percent", 0xFFFFFFFF)
+	mmap := map[string]*Measurement{"grade": m}
+	for _, grade := range []float64{-5, -2, -8} {
+		addValue(mmap, "grade", grade)
+	}
+
+	if m.Maximum != -2 || m.Minimum != -8 {
+		t.Errorf("got maximum %v, minimum %v, expected -2, -8", m.Maximum, m.Minimum)
+	}
+}
@@ -0,0 +1,176 @@
+package fit
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+
+	"github.com/subtlepseudonym/fit-go"
+)
+
+// measurementNamePattern matches names that are safe to use as parquet
+// columns, CSV headers, and line protocol fields without escaping
+var measurementNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedMeasurementNames are column names written alongside measurements
+// in CSV and parquet exports
+var reservedMeasurementNames = map[string]struct{}{
+	TimestampColumn: {},
+	"file_checksum": {},
+	"type":          {},
+}
+
+// Extractor returns a measurement value from a record
+type Extractor func(record *fit.RecordMsg) interface{}
+
+// MeasurementDefinition describes a measurement and how it is read from
+// records. Measurements without an extractor are derived from multiple
+// records or read from non-record messages.
+type MeasurementDefinition struct {
+	Name  string
+	Unit  string
+	Unset uint
+
+	// Types limits the activity types the measurement applies to; the
+	// measurement applies to all types not in ExcludeTypes if empty
+	Types        []string
+	ExcludeTypes []string
+
+	Extract Extractor
+}
+
+// AppliesTo returns whether the measurement is recorded for the activity
+// type
+func (d *MeasurementDefinition) AppliesTo(activityType string) bool {
+	for _, t := range d.ExcludeTypes {
+		if t == activityType {
+			return false
+		}
+	}
+	if len(d.Types) == 0 {
+		return true
+	}
+	for _, t := range d.Types {
+		if t == activityType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MeasurementRegistry contains the measurements read from files
+type MeasurementRegistry struct {
+	definitions map[string]*MeasurementDefinition
+	names       []string
+}
+
+func NewMeasurementRegistry() *MeasurementRegistry {
+	return &MeasurementRegistry{
+		definitions: make(map[string]*MeasurementDefinition),
+	}
+}
+
+// Register adds the measurement to the registry, replacing any existing
+// measurement with the same name
+func (r *MeasurementRegistry) Register(definition MeasurementDefinition) error {
+	if definition.Name == "" {
+		return fmt.Errorf("measurement name is required")
+	}
+	if !measurementNamePattern.MatchString(definition.Name) {
+		return fmt.Errorf("measurement %q: name must contain only letters, digits, and underscores, and not start with a digit", definition.Name)
+	}
+	if _, ok := reservedMeasurementNames[definition.Name]; ok {
+		return fmt.Errorf("measurement %q: name is reserved", definition.Name)
+	}
+	if definition.Unset == 0 {
+		return fmt.Errorf("measurement %q: unset value is required", definition.Name)
+	}
+
+	if _, ok := r.definitions[definition.Name]; !ok {
+		r.names = append(r.names, definition.Name)
+		sort.Strings(r.names)
+	}
+	r.definitions[definition.Name] = &definition
+
+	return nil
+}
+
+func (r *MeasurementRegistry) Lookup(name string) (*MeasurementDefinition, bool) {
+	definition, ok := r.definitions[name]
+	return definition, ok
+}
+
+// Names returns the sorted names of measurements that apply to the
+// activity type
+func (r *MeasurementRegistry) Names(activityType string) []string {
+	names := make([]string, 0, len(r.names))
+	for _, name := range r.names {
+		if r.definitions[name].AppliesTo(activityType) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// AllNames returns the sorted names of all registered measurements
+func (r *MeasurementRegistry) AllNames() []string {
+	names := make([]string, len(r.names))
+	copy(names, r.names)
+	return names
+}
+
+// IsUnset returns whether the value is the measurement's unset value.
+// Values for unregistered measurements are always unset.
+func (r *MeasurementRegistry) IsUnset(key string, value float64) bool {
+	if math.IsNaN(value) {
+		return true
+	}
+
+	if definition, ok := r.definitions[key]; ok {
+		return value >= float64(definition.Unset)
+	}
+
+	return true
+}
+
+// measurementMap returns the set of measurements that apply to the provided
+// activity type
+func (r *MeasurementRegistry) measurementMap(activityType string) map[string]*Measurement {
+	mmap := make(map[string]*Measurement)
+	for _, name := range r.Names(activityType) {
+		definition := r.definitions[name]
+		mmap[name] = NewMeasurement(name, definition.Unit, definition.Unset)
+	}
+
+	return mmap
+}
+
+// FieldExtractor returns an extractor for the named exported field of
+// fit.RecordMsg. Fields must have an integer or floating point kind.
+func FieldExtractor(field string) (Extractor, error) {
+	f, ok := reflect.TypeOf(fit.RecordMsg{}).FieldByName(field)
+	if !ok || !f.IsExported() {
+		return nil, fmt.Errorf("record field %q not found", field)
+	}
+
+	switch f.Type.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(record *fit.RecordMsg) interface{} {
+			return reflect.ValueOf(record).Elem().FieldByIndex(f.Index).Uint()
+		}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(record *fit.RecordMsg) interface{} {
+			return reflect.ValueOf(record).Elem().FieldByIndex(f.Index).Int()
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func(record *fit.RecordMsg) interface{} {
+			return reflect.ValueOf(record).Elem().FieldByIndex(f.Index).Float()
+		}, nil
+	}
+
+	return nil, fmt.Errorf("record field %q: unsupported kind %s", field, f.Type.Kind())
+}
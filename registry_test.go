@@ -0,0 +1,70 @@
+package fit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/subtlepseudonym/fit-go"
+)
+
+func TestRegisterValidatesName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{name: "heart_rate", valid: true},
+		{name: "Core_Temp2", valid: true},
+		{name: "_private", valid: true},
+		{name: ""},
+		{name: "2nd_power"},
+		{name: "power, type=INT64"},
+		{name: "name=power"},
+		{name: "core temp"},
+		{name: "power\n"},
+		{name: "timestamp"},
+		{name: "file_checksum"},
+		{name: "type"},
+	}
+
+	for _, test := range tests {
+		registry := NewMeasurementRegistry()
+		err := registry.Register(MeasurementDefinition{Name: test.name, Unset: 0xFF})
+		if (err == nil) != test.valid {
+			t.Errorf("%q: got error %v, expected valid %t", test.name, err, test.valid)
+		}
+	}
+}
+
+func TestWithRegistry(t *testing.T) {
+	registry := NewMeasurementRegistry()
+	err := registry.Register(MeasurementDefinition{
+		Name:    "heart_rate_reserve",
+		Unit:    "1 / minute",
+		Unset:   0xFF,
+		Extract: func(r *fit.RecordMsg) interface{} { return 190 - int(r.HeartRate) },
+	})
+	if err != nil {
+		t.Fatalf("register: %s", err)
+	}
+
+	start := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+	lap := NewLapSummary(0, start, start.Add(time.Minute), TypeCycling, WithRegistry(registry))
+	if _, ok := lap.mmap["heart_rate"]; ok || len(lap.mmap) != 1 {
+		t.Errorf("got measurements %v, expected only heart_rate_reserve", lap.mmap)
+	}
+
+	for i, heartRate := range []uint8{150, 160} {
+		record := fit.NewRecordMsg()
+		record.Timestamp = start.Add(time.Duration(i) * time.Second)
+		record.HeartRate = heartRate
+		err = lap.ReadRecord(record)
+		if err != nil {
+			t.Fatalf("read record: %s", err)
+		}
+	}
+
+	measurements := lap.FinalizeMeasurements([]string{"heart_rate_reserve"})
+	if len(measurements) != 1 || measurements[0].Maximum != 40 {
+		t.Errorf("got measurements %+v, expected heart_rate_reserve of 40", measurements)
+	}
+}
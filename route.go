@@ -0,0 +1,147 @@
+package fit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/subtlepseudonym/fit-go"
+)
+
+// earthRadius is the mean radius of the earth in meters
+const earthRadius = 6371008.8
+
+// ErrNoPositions is returned when writing a route for an activity with
+// fewer than the two recorded positions required for a line
+var ErrNoPositions = errors.New("activity contains fewer than two positions")
+
+// routePoint is a recorded position with values that are NaN if unset
+type routePoint struct {
+	Latitude  float64
+	Longitude float64
+	Time      time.Time
+	Altitude  float64
+	HeartRate float64
+	Speed     float64 // meters per second
+}
+
+// routePoints returns the records with positions as route points, or
+// ErrNoPositions if fewer than two records have positions
+func routePoints(records []*fit.RecordMsg) ([]routePoint, error) {
+	points := make([]routePoint, 0, len(records))
+	for _, record := range records {
+		lat, lon := record.PositionLat.Degrees(), record.PositionLong.Degrees()
+		if math.IsNaN(lat) || math.IsNaN(lon) {
+			continue
+		}
+
+		point := routePoint{
+			Latitude:  lat,
+			Longitude: lon,
+			Time:      record.Timestamp,
+			Altitude:  record.GetEnhancedAltitudeScaled(),
+			HeartRate: math.NaN(),
+			Speed:     record.GetEnhancedSpeedScaled(),
+		}
+		if !IsUnset("heart_rate", float64(record.HeartRate)) {
+			point.HeartRate = float64(record.HeartRate)
+		}
+		points = append(points, point)
+	}
+	if len(points) < 2 {
+		return nil, ErrNoPositions
+	}
+
+	return points, nil
+}
+
+// simplifyRoute reduces the number of points using the Douglas-Peucker
+// algorithm, keeping points that deviate from the simplified route by more
+// than tolerance meters
+func simplifyRoute(points []routePoint, tolerance float64) []routePoint {
+	if tolerance <= 0 || len(points) < 3 {
+		return points
+	}
+
+	// project onto a plane in meters; the equirectangular approximation
+	// is accurate over the extent of a single activity
+	refLat := points[0].Latitude * math.Pi / 180
+	project := func(p routePoint) (float64, float64) {
+		x := p.Longitude * math.Pi / 180 * math.Cos(refLat) * earthRadius
+		y := p.Latitude * math.Pi / 180 * earthRadius
+		return x, y
+	}
+
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+
+	stack := [][2]int{{0, len(points) - 1}}
+	for len(stack) > 0 {
+		first, last := stack[len(stack)-1][0], stack[len(stack)-1][1]
+		stack = stack[:len(stack)-1]
+
+		ax, ay := project(points[first])
+		bx, by := project(points[last])
+
+		index, max := -1, tolerance
+		for i := first + 1; i < last; i++ {
+			px, py := project(points[i])
+			if d := segmentDistance(px, py, ax, ay, bx, by); d > max {
+				index, max = i, d
+			}
+		}
+		if index < 0 {
+			continue
+		}
+
+		keep[index] = true
+		stack = append(stack, [2]int{first, index}, [2]int{index, last})
+	}
+
+	simplified := make([]routePoint, 0, len(points))
+	for i, point := range points {
+		if keep[i] {
+			simplified = append(simplified, point)
+		}
+	}
+
+	return simplified
+}
+
+// segmentDistance returns the distance from point p to the segment ab
+func segmentDistance(px, py, ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	if dx == 0 && dy == 0 {
+		return math.Hypot(px-ax, py-ay)
+	}
+
+	t := ((px-ax)*dx + (py-ay)*dy) / (dx*dx + dy*dy)
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(px-(ax+t*dx), py-(ay+t*dy))
+}
+
+// activityRecords returns the records of an activity file
+func activityRecords(data *fit.File) ([]*fit.RecordMsg, error) {
+	if data.Type() != fit.FileTypeActivity {
+		return nil, fmt.Errorf("unsupported file type: %d", data.Type())
+	}
+
+	activityData, err := data.Activity()
+	if err != nil {
+		return nil, fmt.Errorf("activity: %w", err)
+	}
+	if len(activityData.Records) == 0 {
+		return nil, ErrNoRecords
+	}
+
+	return activityData.Records, nil
+}
+
+// nullIfNaN returns nil for unset values so they are encoded as null
+func nullIfNaN(v float64) *float64 {
+	if math.IsNaN(v) {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,40 @@
+package fit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/subtlepseudonym/fit-go"
+)
+
+func TestRoutePointsRequiresPositions(t *testing.T) {
+	record := func(lat, lon float64) *fit.RecordMsg {
+		r := fit.NewRecordMsg()
+		r.PositionLat = fit.NewLatitudeDegrees(lat)
+		r.PositionLong = fit.NewLongitudeDegrees(lon)
+		return r
+	}
+	indoor := fit.NewRecordMsg()
+
+	tests := []struct {
+		name    string
+		records []*fit.RecordMsg
+		points  int
+		err     error
+	}{
+		{name: "no records", err: ErrNoPositions},
+		{name: "no positions", records: []*fit.RecordMsg{indoor, indoor}, err: ErrNoPositions},
+		{name: "one position", records: []*fit.RecordMsg{indoor, record(40, -74)}, err: ErrNoPositions},
+		{name: "two positions", records: []*fit.RecordMsg{record(40, -74), indoor, record(40.001, -74)}, points: 2},
+	}
+
+	for _, test := range tests {
+		points, err := routePoints(test.records)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: got error %v, expected %v", test.name, err, test.err)
+		}
+		if len(points) != test.points {
+			t.Errorf("%s: got %d points, expected %d", test.name, len(points), test.points)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package fit
+
+import (
+	"fmt"
+
+	"github.com/subtlepseudonym/fit-go"
+)
+
+// Source identifies the import run and file an activity was loaded from
+type Source struct {
+	ImportID string
+	Checksum string // hex encoded sha256 of the file contents
+	Filename string
+}
+
+// Sink is a destination for activity summaries and records. Each file is
+// written between Begin and Commit, with the summary written before the
+// records, and discarded by Rollback if any sink fails.
+type Sink interface {
+	Begin() error
+	WriteSummary(activity *Activity, source Source) error
+	WriteRecords(data *fit.File, tags map[string]string) error
+	Commit() error
+	Rollback() error
+	Close() error
+}
+
+// Load writes the activity summary and file records to each sink. Sinks are
+// committed in order, so a failed commit rolls back the sinks following it,
+// but not those already committed.
+func Load(sinks []Sink, data *fit.File, activity *Activity, source Source) error {
+	for i, sink := range sinks {
+		err := sink.Begin()
+		if err != nil {
+			return rollback(sinks[:i], fmt.Errorf("begin: %w", err))
+		}
+	}
+
+	for _, sink := range sinks {
+		err := sink.WriteSummary(activity, source)
+		if err != nil {
+			return rollback(sinks, fmt.Errorf("write summary: %w", err))
+		}
+
+		err = sink.WriteRecords(data, activity.Tags)
+		if err != nil {
+			return rollback(sinks, fmt.Errorf("write records: %w", err))
+		}
+	}
+
+	for i, sink := range sinks {
+		err := sink.Commit()
+		if err != nil {
+			return rollback(sinks[i+1:], fmt.Errorf("commit: %w", err))
+		}
+	}
+
+	return nil
+}
+
+// rollback rolls back each sink, returning err annotated with any rollback
+// failures
+func rollback(sinks []Sink, err error) error {
+	for _, sink := range sinks {
+		if rbErr := sink.Rollback(); rbErr != nil {
+			err = fmt.Errorf("%w; rollback: %s", err, rbErr)
+		}
+	}
+	return err
+}
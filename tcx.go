@@ -0,0 +1,286 @@
+package fit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/subtlepseudonym/fit-go"
+)
+
+const (
+	tcxNamespace                  = "http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2"
+	tcxActivityExtensionNamespace = "http://www.garmin.com/xmlschemas/ActivityExtension/v2"
+)
+
+// TCX only defines running, biking, and other sports
+const (
+	TCXSportRunning = "Running"
+	TCXSportBiking  = "Biking"
+	TCXSportOther   = "Other"
+)
+
+// TypeToTCXSport maps activity types to TCX sports. Types that are not
+// mapped are written as TCXSportOther.
+var TypeToTCXSport = map[string]string{
+	TypeCycling: TCXSportBiking,
+	"mountain":  TCXSportBiking,
+	"run":       TCXSportRunning,
+	"treadmill": TCXSportRunning,
+}
+
+type tcx struct {
+	XMLName    xml.Name      `xml:"TrainingCenterDatabase"`
+	Xmlns      string        `xml:"xmlns,attr"`
+	Ns3        string        `xml:"xmlns:ns3,attr"`
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Sport string    `xml:"Sport,attr"`
+	ID    string    `xml:"Id"`
+	Laps  []*tcxLap `xml:"Lap"`
+}
+
+// tcxLap fields must be ordered according to the TCX schema
+type tcxLap struct {
+	StartTime        string          `xml:"StartTime,attr"`
+	TotalTimeSeconds float64         `xml:"TotalTimeSeconds"`
+	DistanceMeters   float64         `xml:"DistanceMeters"`
+	MaximumSpeed     *float64        `xml:"MaximumSpeed,omitempty"`
+	Calories         uint16          `xml:"Calories"`
+	AverageHeartRate *tcxValue       `xml:"AverageHeartRateBpm,omitempty"`
+	MaximumHeartRate *tcxValue       `xml:"MaximumHeartRateBpm,omitempty"`
+	Intensity        string          `xml:"Intensity"`
+	Cadence          *uint8          `xml:"Cadence,omitempty"`
+	TriggerMethod    string          `xml:"TriggerMethod"`
+	Trackpoints      []tcxTrackpoint `xml:"Track>Trackpoint"`
+
+	start, end time.Time
+}
+
+type tcxValue struct {
+	Value uint8 `xml:"Value"`
+}
+
+type tcxPosition struct {
+	Latitude  string `xml:"LatitudeDegrees"`
+	Longitude string `xml:"LongitudeDegrees"`
+}
+
+// tcxTrackpoint fields must be ordered according to the TCX schema
+type tcxTrackpoint struct {
+	Time           string        `xml:"Time"`
+	Position       *tcxPosition  `xml:"Position,omitempty"`
+	AltitudeMeters string        `xml:"AltitudeMeters,omitempty"`
+	DistanceMeters string        `xml:"DistanceMeters,omitempty"`
+	HeartRate      *tcxValue     `xml:"HeartRateBpm,omitempty"`
+	Cadence        *uint8        `xml:"Cadence,omitempty"`
+	Extensions     *tcxExtension `xml:"Extensions>ns3:TPX,omitempty"`
+}
+
+type tcxExtension struct {
+	Speed string  `xml:"ns3:Speed,omitempty"`
+	Watts *uint16 `xml:"ns3:Watts,omitempty"`
+}
+
+// WriteTCX writes activity laps and records as Training Center XML
+func WriteTCX(out io.Writer, data *fit.File) error {
+	if data.Type() != fit.FileTypeActivity {
+		return fmt.Errorf("unsupported file type: %d", data.Type())
+	}
+
+	activityType, err := Type(data)
+	if err != nil {
+		return fmt.Errorf("type: %w", err)
+	}
+
+	activityData, err := data.Activity()
+	if err != nil {
+		return fmt.Errorf("activity: %w", err)
+	}
+
+	records := activityData.Records
+	if len(records) == 0 {
+		return ErrNoRecords
+	}
+
+	sport, ok := TypeToTCXSport[activityType.Type]
+	if !ok {
+		sport = TCXSportOther
+	}
+
+	laps := newTCXLaps(activityData.Laps)
+	if len(laps) == 0 {
+		laps = []*tcxLap{newTCXRecordLap(records)}
+	}
+	for _, record := range records {
+		for _, lap := range laps {
+			if record.Timestamp.Before(lap.start) || record.Timestamp.After(lap.end) {
+				continue
+			}
+			lap.Trackpoints = append(lap.Trackpoints, newTCXTrackpoint(record))
+			break
+		}
+	}
+
+	doc := tcx{
+		Xmlns: tcxNamespace,
+		Ns3:   tcxActivityExtensionNamespace,
+		Activities: []tcxActivity{
+			{
+				Sport: sport,
+				ID:    records[0].Timestamp.UTC().Format(time.RFC3339),
+				Laps:  laps,
+			},
+		},
+	}
+
+	_, err = io.WriteString(out, xml.Header)
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(doc)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	_, err = io.WriteString(out, "\n")
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	return nil
+}
+
+func newTCXLaps(laps []*fit.LapMsg) []*tcxLap {
+	tcxLaps := make([]*tcxLap, 0, len(laps))
+	for _, lap := range laps {
+		if lap == nil || lap.StartTime.IsZero() || lap.Timestamp.IsZero() {
+			continue
+		}
+
+		l := &tcxLap{
+			StartTime:        lap.StartTime.UTC().Format(time.RFC3339),
+			TotalTimeSeconds: zeroIfNaN(lap.GetTotalTimerTimeScaled()),
+			DistanceMeters:   zeroIfNaN(lap.GetTotalDistanceScaled()),
+			Intensity:        "Active",
+			TriggerMethod:    tcxTriggerMethod(lap.LapTrigger),
+			start:            lap.StartTime,
+			end:              lap.Timestamp,
+		}
+		if speed := lap.GetEnhancedMaxSpeedScaled(); !math.IsNaN(speed) {
+			l.MaximumSpeed = &speed
+		}
+		if lap.TotalCalories != 0xFFFF {
+			l.Calories = lap.TotalCalories
+		}
+		if !IsUnset("heart_rate", float64(lap.AvgHeartRate)) {
+			l.AverageHeartRate = &tcxValue{lap.AvgHeartRate}
+		}
+		if !IsUnset("heart_rate", float64(lap.MaxHeartRate)) {
+			l.MaximumHeartRate = &tcxValue{lap.MaxHeartRate}
+		}
+		if lap.Intensity == fit.IntensityRest {
+			l.Intensity = "Resting"
+		}
+		if !IsUnset("cadence", float64(lap.AvgCadence)) {
+			cadence := lap.AvgCadence
+			l.Cadence = &cadence
+		}
+
+		tcxLaps = append(tcxLaps, l)
+	}
+
+	return tcxLaps
+}
+
+// newTCXRecordLap returns a single lap covering all records for files
+// without lap messages
+func newTCXRecordLap(records []*fit.RecordMsg) *tcxLap {
+	start, end := records[0].Timestamp, records[len(records)-1].Timestamp
+	lap := &tcxLap{
+		StartTime:        start.UTC().Format(time.RFC3339),
+		TotalTimeSeconds: end.Sub(start).Seconds(),
+		Intensity:        "Active",
+		TriggerMethod:    "Manual",
+		start:            start,
+		end:              end,
+	}
+
+	for i := len(records) - 1; i >= 0; i-- {
+		if distance := records[i].GetDistanceScaled(); !math.IsNaN(distance) {
+			lap.DistanceMeters = distance
+			break
+		}
+	}
+
+	return lap
+}
+
+func newTCXTrackpoint(record *fit.RecordMsg) tcxTrackpoint {
+	point := tcxTrackpoint{
+		Time: record.Timestamp.UTC().Format(time.RFC3339),
+	}
+
+	lat, lon := record.PositionLat.Degrees(), record.PositionLong.Degrees()
+	if !math.IsNaN(lat) && !math.IsNaN(lon) {
+		point.Position = &tcxPosition{
+			Latitude:  strconv.FormatFloat(lat, 'f', -1, 64),
+			Longitude: strconv.FormatFloat(lon, 'f', -1, 64),
+		}
+	}
+	if altitude := record.GetEnhancedAltitudeScaled(); !math.IsNaN(altitude) {
+		point.AltitudeMeters = strconv.FormatFloat(altitude, 'f', 1, 64)
+	}
+	if distance := record.GetDistanceScaled(); !math.IsNaN(distance) {
+		point.DistanceMeters = strconv.FormatFloat(distance, 'f', 2, 64)
+	}
+	if !IsUnset("heart_rate", float64(record.HeartRate)) {
+		point.HeartRate = &tcxValue{record.HeartRate}
+	}
+	if !IsUnset("cadence", float64(record.Cadence)) {
+		cadence := record.Cadence
+		point.Cadence = &cadence
+	}
+
+	var ext tcxExtension
+	if speed := record.GetEnhancedSpeedScaled(); !math.IsNaN(speed) {
+		ext.Speed = strconv.FormatFloat(speed, 'f', 3, 64)
+	}
+	if !IsUnset("power", float64(record.Power)) {
+		power := record.Power
+		ext.Watts = &power
+	}
+	if ext != (tcxExtension{}) {
+		point.Extensions = &ext
+	}
+
+	return point
+}
+
+func tcxTriggerMethod(trigger fit.LapTrigger) string {
+	switch trigger {
+	case fit.LapTriggerTime:
+		return "Time"
+	case fit.LapTriggerDistance:
+		return "Distance"
+	case fit.LapTriggerPositionStart, fit.LapTriggerPositionLap, fit.LapTriggerPositionWaypoint, fit.LapTriggerPositionMarked:
+		return "Location"
+	}
+
+	return "Manual"
+}
+
+func zeroIfNaN(v float64) float64 {
+	if math.IsNaN(v) {
+		return 0
+	}
+	return v
+}
@@ -14,62 +14,175 @@ const (
 	TypeUnknown    = "unknown"
 )
 
-// Use Sport.Name mapping to capture custom activities
-var sportToType map[string]string = map[string]string{
-	SportTracking:       TypeTracking,
-	"American Football": "football",
-	"Basketball":        "basketball",
-	"Bike":              TypeCycling,
-	"Cooldown":          "cooldown",
-	"Hike":              "hike",
-	"Ice Skate":         "iceskate",
-	"Kayak":             "kayak",
-	"MTB":               "mountain",
-	"Open Water":        "openwater",
-	"Pool Swim":         "swim",
-	"Run":               "run",
-	"SUP":               "paddleboard",
-	"Ski":               "ski",
-	"Snowboard":         "snowboard",
-	"Soccer":            "soccer",
-	"Strength":          "strength",
-	"Tennis":            "tennis",
-	"Treadmill":         "treadmill",
-	"Walk":              "walk",
-	"Yoga":              "yoga",
+// Rules describing how an activity type was resolved
+const (
+	RuleName     = "name"
+	RuleSubSport = "sub_sport"
+	RuleSport    = "sport"
+	RuleFileType = "file_type"
+	RuleDefault  = "default"
+)
+
+// ActivityType describes a file's activity type and the sport information
+// it was resolved from
+type ActivityType struct {
+	Type     string `json:"type"`
+	Sport    string `json:"sport,omitempty"`
+	SubSport string `json:"sub_sport,omitempty"`
+	Name     string `json:"name,omitempty"` // raw Sport.Name
+	Rule     string `json:"rule"`
+}
+
+func (t ActivityType) String() string {
+	return t.Type
+}
+
+// TypeMapping maps sport names and FIT sport enums to activity types. Sport
+// names are matched first in order to capture custom activities, followed
+// by sub-sport and sport, which are keyed by their FIT profile names, such
+// as "GravelCycling" or "Cycling".
+type TypeMapping struct {
+	Names     map[string]string `json:"names" yaml:"names"`
+	SubSports map[string]string `json:"sub_sports" yaml:"sub_sports"`
+	Sports    map[string]string `json:"sports" yaml:"sports"`
+}
+
+// DefaultTypeMapping is used for resolving activity types and may be
+// extended by the caller
+var DefaultTypeMapping = &TypeMapping{
+	Names: map[string]string{
+		SportTracking:       TypeTracking,
+		"American Football": "football",
+		"Basketball":        "basketball",
+		"Bike":              TypeCycling,
+		"Cooldown":          "cooldown",
+		"Hike":              "hike",
+		"Ice Skate":         "iceskate",
+		"Kayak":             "kayak",
+		"MTB":               "mountain",
+		"Open Water":        "openwater",
+		"Pool Swim":         "swim",
+		"Run":               "run",
+		"SUP":               "paddleboard",
+		"Ski":               "ski",
+		"Snowboard":         "snowboard",
+		"Soccer":            "soccer",
+		"Strength":          "strength",
+		"Tennis":            "tennis",
+		"Treadmill":         "treadmill",
+		"Walk":              "walk",
+		"Yoga":              "yoga",
+	},
+	SubSports: map[string]string{
+		fit.SubSportGravelCycling.String():    TypeCycling,
+		fit.SubSportIndoorCycling.String():    TypeCycling,
+		fit.SubSportIndoorRowing.String():     "row",
+		fit.SubSportLapSwimming.String():      "swim",
+		fit.SubSportMountain.String():         "mountain",
+		fit.SubSportOpenWater.String():        "openwater",
+		fit.SubSportStrengthTraining.String(): "strength",
+		fit.SubSportTreadmill.String():        "treadmill",
+		fit.SubSportYoga.String():             "yoga",
+	},
+	Sports: map[string]string{
+		fit.SportAlpineSkiing.String():          "ski",
+		fit.SportAmericanFootball.String():      "football",
+		fit.SportBasketball.String():            "basketball",
+		fit.SportCycling.String():               TypeCycling,
+		fit.SportEBiking.String():               TypeCycling,
+		fit.SportHiking.String():                "hike",
+		fit.SportIceSkating.String():            "iceskate",
+		fit.SportKayaking.String():              "kayak",
+		fit.SportRowing.String():                "row",
+		fit.SportRunning.String():               "run",
+		fit.SportSnowboarding.String():          "snowboard",
+		fit.SportSoccer.String():                "soccer",
+		fit.SportStandUpPaddleboarding.String(): "paddleboard",
+		fit.SportSwimming.String():              "swim",
+		fit.SportTennis.String():                "tennis",
+		fit.SportWalking.String():               "walk",
+	},
+}
+
+// Merge adds the other mapping's rules, replacing existing rules for the
+// same keys
+func (m *TypeMapping) Merge(other *TypeMapping) {
+	merge := func(dst *map[string]string, src map[string]string) {
+		if *dst == nil {
+			*dst = make(map[string]string, len(src))
+		}
+		for key, value := range src {
+			(*dst)[key] = value
+		}
+	}
+
+	merge(&m.Names, other.Names)
+	merge(&m.SubSports, other.SubSports)
+	merge(&m.Sports, other.Sports)
 }
 
-func Type(data *fit.File) (string, error) {
+// Resolve returns the activity type for the sport name, sub-sport, and
+// sport, in that order of precedence
+func (m *TypeMapping) Resolve(name string, sport fit.Sport, subSport fit.SubSport) ActivityType {
+	t := ActivityType{
+		Type: TypeUnknown,
+		Name: name,
+		Rule: RuleDefault,
+	}
+	if sport != fit.SportInvalid {
+		t.Sport = sport.String()
+	}
+	// generic sub-sport provides no information beyond the sport
+	if subSport != fit.SubSportInvalid && subSport != fit.SubSportGeneric {
+		t.SubSport = subSport.String()
+	}
+
+	if typ, ok := m.Names[name]; ok && name != "" {
+		t.Type, t.Rule = typ, RuleName
+	} else if typ, ok := m.SubSports[t.SubSport]; ok && t.SubSport != "" {
+		t.Type, t.Rule = typ, RuleSubSport
+	} else if typ, ok := m.Sports[t.Sport]; ok && t.Sport != "" {
+		t.Type, t.Rule = typ, RuleSport
+	}
+
+	return t
+}
+
+func (m *TypeMapping) resolveSport(sport *fit.SportMsg) ActivityType {
+	if sport == nil {
+		return m.Resolve("", fit.SportInvalid, fit.SubSportInvalid)
+	}
+	return m.Resolve(sport.Name, sport.Sport, sport.SubSport)
+}
+
+// Type returns the activity type of the file using DefaultTypeMapping
+func Type(data *fit.File) (ActivityType, error) {
 	switch data.Type() {
 	case fit.FileTypeActivity:
 		activity, err := data.Activity()
 		if err != nil {
-			return "", fmt.Errorf("activity: %w", err)
+			return ActivityType{}, fmt.Errorf("activity: %w", err)
 		}
+
+		// fall back to the first session's sport if the sport message
+		// is missing
 		if activity.Sport == nil {
-			return TypeUnknown, nil
-		}
-		if t, ok := sportToType[activity.Sport.Name]; ok {
-			return t, nil
-		} else {
-			return TypeUnknown, nil
+			for _, session := range activity.Sessions {
+				if session != nil {
+					return DefaultTypeMapping.Resolve("", session.Sport, session.SubSport), nil
+				}
+			}
 		}
+		return DefaultTypeMapping.resolveSport(activity.Sport), nil
 	case fit.FileTypeSport:
 		sport, err := data.Sport()
 		if err != nil {
-			return "", fmt.Errorf("sport: %w", err)
-		}
-		if sport.Sport == nil {
-			return TypeUnknown, nil
-		}
-		if t, ok := sportToType[sport.Sport.Name]; ok {
-			return t, nil
-		} else {
-			return TypeUnknown, nil
+			return ActivityType{}, fmt.Errorf("sport: %w", err)
 		}
+		return DefaultTypeMapping.resolveSport(sport.Sport), nil
 	case fit.FileTypeMonitoringA, fit.FileTypeMonitoringB, fit.FileTypeMonitoringDaily:
-		return TypeMonitoring, nil
+		return ActivityType{Type: TypeMonitoring, Rule: RuleFileType}, nil
 	}
 
-	return TypeUnknown, fmt.Errorf("file type unknown")
+	return ActivityType{Type: TypeUnknown, Rule: RuleDefault}, fmt.Errorf("file type unknown")
 }
@@ -0,0 +1,107 @@
+package fit
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+var (
+	// DefaultMaxHeartRateZones are zone lower bounds as a fraction of
+	// maximum heart rate
+	DefaultMaxHeartRateZones = []float64{0.5, 0.6, 0.7, 0.8, 0.9}
+
+	// DefaultThresholdHeartRateZones are zone lower bounds as a fraction
+	// of lactate threshold heart rate
+	DefaultThresholdHeartRateZones = []float64{0.81, 0.9, 0.94, 1.0, 1.03}
+)
+
+// Zones contains the lower bound of each zone in ascending order. Values
+// below the first bound are in zone 0.
+type Zones []float64
+
+// NewZones returns zones from explicit lower bounds
+func NewZones(bounds []float64) (Zones, error) {
+	if len(bounds) == 0 {
+		return nil, fmt.Errorf("no zone bounds")
+	}
+
+	zones := make(Zones, len(bounds))
+	copy(zones, bounds)
+	if !sort.Float64sAreSorted(zones) {
+		return nil, fmt.Errorf("zone bounds must be ascending")
+	}
+
+	return zones, nil
+}
+
+// NewMaxHeartRateZones returns the default zones relative to maximum
+// heart rate
+func NewMaxHeartRateZones(max float64) Zones {
+	return scaleZones(DefaultMaxHeartRateZones, max)
+}
+
+// NewThresholdHeartRateZones returns the default zones relative to lactate
+// threshold heart rate
+func NewThresholdHeartRateZones(threshold float64) Zones {
+	return scaleZones(DefaultThresholdHeartRateZones, threshold)
+}
+
+func scaleZones(fractions []float64, value float64) Zones {
+	zones := make(Zones, len(fractions))
+	for i, f := range fractions {
+		zones[i] = math.Round(f * value)
+	}
+	return zones
+}
+
+// Zone returns the zone number containing the value
+func (z Zones) Zone(value float64) int {
+	return sort.Search(len(z), func(i int) bool {
+		return z[i] > value
+	})
+}
+
+// ZoneTime contains the time spent within a single zone
+type ZoneTime struct {
+	Zone     int     `json:"zone"`
+	Minimum  float64 `json:"minimum"`
+	Maximum  float64 `json:"maximum,omitempty"`
+	Duration float64 `json:"duration"` // seconds
+}
+
+// CalculateTimeInZones calculates time in each zone for the measurement,
+// weighting each value by the time until the next record
+func (a *Activity) CalculateTimeInZones(measurement string, zones Zones) []*ZoneTime {
+	m, ok := a.mmap[measurement]
+	if !ok || len(zones) == 0 || len(m.values) != len(a.timestamps) {
+		return nil
+	}
+
+	times := make([]*ZoneTime, len(zones)+1)
+	for i := range times {
+		times[i] = &ZoneTime{Zone: i}
+		if i > 0 {
+			times[i].Minimum = zones[i-1]
+		}
+		if i < len(zones) {
+			times[i].Maximum = zones[i]
+		}
+	}
+
+	for i := 0; i < len(m.values)-1; i++ {
+		v := m.values[i]
+		if v >= float64(m.unset) {
+			continue
+		}
+
+		interval := a.timestamps[i+1].Sub(a.timestamps[i])
+		if interval <= 0 || interval > RecordMaxGap {
+			continue
+		}
+
+		times[zones.Zone(v)].Duration += interval.Seconds()
+	}
+
+	return times
+}